@@ -0,0 +1,194 @@
+package log
+
+import (
+	"time"
+)
+
+// BackpressurePolicy decides what happens to an entry when the entry queue is full.
+type BackpressurePolicy int
+
+const (
+	// BackpressureBlock waits until the queue has room. It is the default.
+	// A full output queue blocks the logging goroutines, but not the dispatch of the entries already logged to the other outputs.
+	BackpressureBlock BackpressurePolicy = iota
+	// BackpressureDropNewest drops the entry being logged.
+	BackpressureDropNewest
+	// BackpressureDropOldest drops the oldest queued entry to make room for the entry being logged.
+	BackpressureDropOldest
+	// BackpressureBlockTimeout waits until the queue has room or the timeout expires, and then drops the entry.
+	BackpressureBlockTimeout
+)
+
+// defaultDropReportInterval is the interval of the dropped entries report when Config.DropReportInterval is not set.
+const defaultDropReportInterval = 10 * time.Second
+
+// send writes the entry to the synchronous outputs and queues it for the others according to the backpressure policy.
+// Dropped entries are counted and reported by the dispatcher goroutine as a WARN entry.
+// It returns the errors of the synchronous outputs.
+func (d *dynamicWriter) send(entry *logEntry) error {
+	var err error
+	d.sendEntry(entry, func(entry *logEntry) {
+		err = d.writeSync(entry)
+		if !d.async.Load() {
+			return
+		}
+		if d.policy == BackpressureBlock {
+			d.waitCongestion()
+		}
+		if dropped := enqueue(d.ch, d.pending, entry, d.policy, d.timeout); dropped > 0 {
+			d.dropped.Add(dropped)
+		}
+	})
+	return err
+}
+
+// enqueue sends the entry to ch according to the policy and returns the number of dropped entries.
+// Ops taken out of ch by BackpressureDropOldest are moved to pending, since ops are never dropped,
+// and the goroutine that reads ch must run pending before the next entry of ch.
+func enqueue(ch, pending chan *logEntry, entry *logEntry, policy BackpressurePolicy, timeout time.Duration) uint64 {
+	switch policy {
+	case BackpressureDropNewest:
+		select {
+		case ch <- entry:
+			return 0
+		default:
+			return 1
+		}
+	case BackpressureDropOldest:
+		var dropped uint64
+		for {
+			select {
+			case ch <- entry:
+				return dropped
+			default:
+			}
+			select {
+			case oldest := <-ch:
+				if oldest.op != nil {
+					// The op was at the head of the queue,
+					// so the dispatcher goroutine runs it before the entries left in the queue.
+					pending <- oldest
+				} else {
+					dropped++
+				}
+			default:
+			}
+		}
+	case BackpressureBlockTimeout:
+		select {
+		case ch <- entry:
+			return 0
+		default:
+		}
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		select {
+		case ch <- entry:
+			return 0
+		case <-timer.C:
+			return 1
+		}
+	default:
+		ch <- entry
+		return 0
+	}
+}
+
+// queue queues the entry to the output according to the policy and returns the number of dropped entries.
+// It runs in the dispatcher goroutine. With BackpressureBlock, an entry that does not fit in the queue
+// is kept in the overflow of the output instead of blocking the dispatcher goroutine,
+// and the logging goroutines wait in send until the overflow is written.
+// So the other outputs still receive every entry logged, and no entry is dropped.
+func (d *dynamicWriter) queue(o *output, entry *logEntry, policy BackpressurePolicy) uint64 {
+	if policy != BackpressureBlock {
+		return enqueue(o.ch, o.pending, entry, policy, d.timeout)
+	}
+	o.overflowMtx.Lock()
+	defer o.overflowMtx.Unlock()
+	if len(o.overflow) == 0 {
+		select {
+		case o.ch <- entry:
+			return 0
+		default:
+			d.setCongested(1)
+		}
+	}
+	o.overflow = append(o.overflow, entry)
+	return 0
+}
+
+// takeOverflow returns the overflow of the output and removes it, or nil if ch is not empty yet.
+func (d *dynamicWriter) takeOverflow(o *output) []*logEntry {
+	o.overflowMtx.Lock()
+	defer o.overflowMtx.Unlock()
+	if len(o.overflow) == 0 || len(o.ch) > 0 {
+		return nil
+	}
+	entries := o.overflow
+	o.overflow = nil
+	d.setCongested(-1)
+	return entries
+}
+
+func (d *dynamicWriter) setCongested(delta int) {
+	d.congestion.L.Lock()
+	defer d.congestion.L.Unlock()
+	d.congested += delta
+	if d.congested == 0 {
+		d.congestion.Broadcast()
+	}
+}
+
+// waitCongestion waits until no output has an overflow. It bounds the overflows by blocking the logging goroutines,
+// and never the dispatcher goroutine, under BackpressureBlock.
+func (d *dynamicWriter) waitCongestion() {
+	d.congestion.L.Lock()
+	defer d.congestion.L.Unlock()
+	for d.congested > 0 {
+		d.congestion.Wait()
+	}
+}
+
+// runPending runs the ops taken out of the queue by BackpressureDropOldest.
+func (d *dynamicWriter) runPending() {
+	for {
+		select {
+		case entry := <-d.pending:
+			d.process(entry)
+		default:
+			return
+		}
+	}
+}
+
+// reportDropped writes a WARN entry with the number of entries dropped since the last report in the dropped field.
+// The report is queued to the outputs with the policy. Close uses BackpressureBlock so the last report is never dropped.
+func (d *dynamicWriter) reportDropped(policy BackpressurePolicy) {
+	dropped := d.dropped.Load()
+	reported := d.reported.Swap(dropped)
+	if dropped == reported {
+		return
+	}
+	entry := &logEntry{
+		t:         time.Now().In(d.location),
+		level:     WARN,
+		threshold: WARN,
+		fields:    []Field{{Key: "dropped", Value: dropped - reported}},
+		format:    "log entries were dropped because the queue was full\n",
+	}
+	_ = d.writeSync(entry)
+	d.dispatchWith(entry, policy)
+}
+
+// Dropped returns the total number of entries dropped by the backpressure policy,
+// from the shared queue and from the queues of the outputs.
+func (l *logger) Dropped() uint64 {
+	return l.dynamicWriter.dropped.Load()
+}
+
+// QueueDepth returns the number of entries waiting in the queue of each output by output name.
+// The built-in outputs are named "console", "file" and "remote",
+// and the empty name is the shared queue that all entries go through before the outputs.
+func (l *logger) QueueDepth() map[string]int {
+	return l.dynamicWriter.queueDepth()
+}
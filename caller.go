@@ -0,0 +1,50 @@
+package log
+
+import (
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// CallerKey is the key of the field that holds the Caller of an entry.
+// The field is attached when the logger is created with WithCaller.
+const CallerKey = "caller"
+
+// callerSkip is the number of frames between runtime.Callers in callerField and the user code.
+// callerField -> log -> logf/logw/logCtx -> Debug/Info/Warn/Error (method or package-level wrapper) -> user code
+const callerSkip = 5
+
+// Caller is the location of the code that logged an entry.
+type Caller struct {
+	File     string `json:"file"`
+	Line     int    `json:"line"`
+	Function string `json:"function"`
+}
+
+// String returns the caller in the form "dir/file.go:line".
+func (c Caller) String() string {
+	file := c.File
+	if i := strings.LastIndexByte(file, '/'); i >= 0 {
+		if j := strings.LastIndexByte(file[:i], '/'); j >= 0 {
+			file = file[j+1:]
+		}
+	}
+	return file + ":" + strconv.Itoa(c.Line)
+}
+
+// callerField returns the caller field of the code that called the logging method.
+func (l *logger) callerField() (Field, bool) {
+	var pcs [1]uintptr
+	if runtime.Callers(callerSkip+l.config.CallerSkip, pcs[:]) == 0 {
+		return Field{}, false
+	}
+	return callerFieldFromPC(pcs[0])
+}
+
+func callerFieldFromPC(pc uintptr) (Field, bool) {
+	frame, _ := runtime.CallersFrames([]uintptr{pc}).Next()
+	if frame.PC == 0 {
+		return Field{}, false
+	}
+	return Field{Key: CallerKey, Value: Caller{File: frame.File, Line: frame.Line, Function: frame.Function}}, true
+}
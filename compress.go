@@ -0,0 +1,151 @@
+package log
+
+import (
+	"compress/gzip"
+	"encoding/binary"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// The suffixes of the compressed log files and of the files being compressed.
+// A file is compressed to name.log.gz.tmp, which is renamed to name.log.gz when it is complete,
+// and then name.log is removed. So a name.log.gz.tmp file is always incomplete and a name.log.gz file always complete.
+const (
+	compressedSuffix     = ".gz"
+	compressingSuffix    = ".gz.tmp"
+	compressedFileSuffix = ".log" + compressedSuffix
+)
+
+// compressRotated compresses the log files of the writer except the active one.
+// The errors are reported to the error handler of the writer.
+func (f *fileWriter) compressRotated() {
+	entries, err := os.ReadDir(f.logPath)
+	if err != nil {
+		f.handleError(err)
+		return
+	}
+	var errs []error
+	for _, entry := range entries {
+		name := entry.Name()
+		if !entry.Type().IsRegular() || !strings.HasSuffix(name, ".log") || !f.isLogFile(name) {
+			continue
+		}
+		path := filepath.Join(f.logPath, name)
+		if !f.rotated(path) {
+			continue
+		}
+		if _, err := os.Stat(path + compressedSuffix); err == nil {
+			// compressed before, possibly by a process that stopped before removing the file
+			continue
+		}
+		if err := compressFile(path); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		f.handleError(err)
+	}
+}
+
+// compressFile compresses path to path.gz with the same modification time and removes path.
+func compressFile(path string) (err error) {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	info, err := src.Stat()
+	if err != nil {
+		return err
+	}
+
+	tmp := path + compressingSuffix
+	dst, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = dst.Close()
+			_ = os.Remove(tmp)
+		}
+	}()
+
+	gz := gzip.NewWriter(dst)
+	if _, err = io.Copy(gz, src); err != nil {
+		return err
+	}
+	if err = gz.Close(); err != nil {
+		return err
+	}
+	if err = dst.Sync(); err != nil {
+		return err
+	}
+	if err = dst.Close(); err != nil {
+		return err
+	}
+	// keep the modification time, so the retention removes the files in the order they were written
+	_ = os.Chtimes(tmp, info.ModTime(), info.ModTime())
+	if err = os.Rename(tmp, path+compressedSuffix); err != nil {
+		return err
+	}
+	return os.Remove(path)
+}
+
+// recoverCompression cleans up after a process that stopped while compressing the log files of the writer.
+// Incomplete name.log.gz.tmp files are removed, and name.log is removed if name.log.gz is a complete copy of it.
+// The errors are reported to the error handler of the writer.
+func (f *fileWriter) recoverCompression() {
+	entries, err := os.ReadDir(f.logPath)
+	if err != nil {
+		f.handleError(err)
+		return
+	}
+	var errs []error
+	for _, entry := range entries {
+		name := entry.Name()
+		path := filepath.Join(f.logPath, name)
+		if plain, ok := strings.CutSuffix(name, compressingSuffix); ok && f.isLogFile(plain) {
+			if err := os.Remove(path); err != nil {
+				errs = append(errs, err)
+			}
+			continue
+		}
+		if !strings.HasSuffix(name, compressedFileSuffix) || !f.isLogFile(name) {
+			continue
+		}
+		plain := strings.TrimSuffix(path, compressedSuffix)
+		info, err := os.Stat(plain)
+		if err != nil {
+			continue
+		}
+		if size, err := uncompressedSize(path); err == nil && size == uint32(info.Size()) {
+			if err := os.Remove(plain); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		f.handleError(err)
+	}
+}
+
+// uncompressedSize returns the size of the uncompressed data modulo 2^32 from the trailer of the gzip file.
+func uncompressedSize(path string) (uint32, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+	var trailer [4]byte
+	if _, err := file.Seek(-4, io.SeekEnd); err != nil {
+		return 0, err
+	}
+	if _, err := io.ReadFull(file, trailer[:]); err != nil {
+		return 0, err
+	}
+	return binary.LittleEndian.Uint32(trailer[:]), nil
+}
@@ -3,6 +3,7 @@ package log
 import (
 	"io"
 	"net/http"
+	"os"
 
 	"time"
 )
@@ -17,6 +18,28 @@ type Config struct {
 	RemoteConfig      *RemoteConfig
 	StandardFormatter Formatter
 	FormatterRegistry *FormatterRegistry
+	ContextExtractors []ContextExtractor
+	NamedLevels       map[string]LogLevel
+	Caller            bool
+	CallerSkip        int
+	StacktraceLevel   LogLevel
+	ExitFunc          func(code int)
+	Verbosity         int
+	VModule           string
+	Writers           []*WriterConfig
+	Global            bool
+
+	Backpressure        BackpressurePolicy
+	BackpressureTimeout time.Duration
+	DropReportInterval  time.Duration
+	AfterClose          AfterClosePolicy
+	ErrorHandler        ErrorHandler
+
+	SyncMode    bool
+	SyncOutputs []string
+
+	ReopenOnSignal bool
+	ReopenSignals  []os.Signal
 }
 
 type FileCreateMode int
@@ -26,13 +49,37 @@ const (
 	HOURLYMODE
 )
 
+// ConsoleConfig, FileConfig and RemoteConfig have their own Level.
+// Entries below the Level are not written to the output.
+// NONE, the default, means the output uses the level of the logger.
+
 type ConsoleConfig struct {
 	Writer io.Writer
+	Level  LogLevel
 }
+
+// MaxSize is the size in bytes at which the file of the current day or hour is continued
+// in a numbered segment, e.g. name.2024-01-01.1.log. Zero means no limit.
+// MaxAge and MaxFiles remove the oldest log files of the logger whenever a new file is opened.
+// Zero means no limit. Compress compresses the previous log files to .log.gz whenever a new file is opened.
+// Pattern and Interval replace the file names and the period of FileCreateMode. See WithFilePattern.
+// DisableSymlink disables the symlink <LogPath>/<logger name>.log to the file being written.
+// BufferSize, FlushInterval and Fsync buffer the writes and sync the file. See WithFileBuffer and WithFileFsync.
 type FileConfig struct {
 	FileName       string
 	LogPath        string
 	FileCreateMode FileCreateMode
+	Level          LogLevel
+	MaxSize        int64
+	MaxAge         time.Duration
+	MaxFiles       int
+	Compress       bool
+	Pattern        string
+	Interval       time.Duration
+	DisableSymlink bool
+	BufferSize     int
+	FlushInterval  time.Duration
+	Fsync          FsyncPolicy
 }
 
 type RemoteConfig struct {
@@ -40,6 +87,14 @@ type RemoteConfig struct {
 	Method    string
 	Header    http.Header
 	Transport *http.RoundTripper
+	Level     LogLevel
+}
+
+// WriterConfig is a custom output registered with WithWriter.
+type WriterConfig struct {
+	Name      string
+	Writer    Writer
+	Formatter Formatter
 }
 
 type FormatterRegistry struct {
@@ -59,11 +114,40 @@ func convertOptions(config *Config) []LogOption {
 	if config.OutputMode != 0 {
 		opts = append(opts, withOutputMode(config.OutputMode))
 	}
+	if config.ConsoleConfig != nil {
+		if config.ConsoleConfig.Writer != nil {
+			opts = append(opts, WithConsoleOutPut(config.ConsoleConfig.Writer))
+		}
+		opts = append(opts, WithConsoleLevel(config.ConsoleConfig.Level))
+	}
 	if config.FileConfig != nil {
 		opts = append(opts, WithFileMode(config.FileConfig.FileName, config.FileConfig.LogPath, config.FileConfig.FileCreateMode))
+		opts = append(opts, WithFileLevel(config.FileConfig.Level))
+		if config.FileConfig.MaxSize > 0 {
+			opts = append(opts, WithFileMaxSize(config.FileConfig.MaxSize))
+		}
+		if config.FileConfig.MaxAge > 0 || config.FileConfig.MaxFiles > 0 {
+			opts = append(opts, WithFileRetention(config.FileConfig.MaxAge, config.FileConfig.MaxFiles))
+		}
+		if config.FileConfig.Pattern != "" || config.FileConfig.Interval > 0 {
+			opts = append(opts, WithFilePattern(config.FileConfig.Pattern, config.FileConfig.Interval))
+		}
+		if config.FileConfig.Compress {
+			opts = append(opts, WithFileCompress())
+		}
+		if config.FileConfig.DisableSymlink {
+			opts = append(opts, WithFileSymlinkOff())
+		}
+		if config.FileConfig.BufferSize > 0 || config.FileConfig.FlushInterval > 0 {
+			opts = append(opts, WithFileBuffer(config.FileConfig.BufferSize, config.FileConfig.FlushInterval))
+		}
+		if config.FileConfig.Fsync != FsyncNever {
+			opts = append(opts, WithFileFsync(config.FileConfig.Fsync))
+		}
 	}
 	if config.RemoteConfig != nil {
 		opts = append(opts, WithRemoteMode(config.RemoteConfig.EndPoint, config.RemoteConfig.Method, config.RemoteConfig.Header, config.RemoteConfig.Transport))
+		opts = append(opts, WithRemoteLevel(config.RemoteConfig.Level))
 	}
 	if config.Location != nil {
 		opts = append(opts, WithLocation(config.Location))
@@ -71,6 +155,54 @@ func convertOptions(config *Config) []LogOption {
 	if config.StandardFormatter != nil {
 		opts = append(opts, WithStandardFormatter(config.StandardFormatter))
 	}
+	for name, level := range config.NamedLevels {
+		opts = append(opts, WithLevelFor(name, level))
+	}
+	if config.Caller {
+		opts = append(opts, WithCaller(config.CallerSkip))
+	}
+	if config.StacktraceLevel != NONE {
+		opts = append(opts, WithStacktrace(config.StacktraceLevel))
+	}
+	if config.ExitFunc != nil {
+		opts = append(opts, WithExitFunc(config.ExitFunc))
+	}
+	if config.Verbosity != 0 {
+		opts = append(opts, WithVerbosity(config.Verbosity))
+	}
+	if config.VModule != "" {
+		opts = append(opts, WithVModule(config.VModule))
+	}
+	if config.Global {
+		opts = append(opts, WithGlobal())
+	}
+	for _, w := range config.Writers {
+		opts = append(opts, WithWriter(w.Name, w.Writer, w.Formatter))
+	}
+	if config.Backpressure != BackpressureBlock {
+		opts = append(opts, WithBackpressure(config.Backpressure, config.BackpressureTimeout))
+	}
+	if config.DropReportInterval != 0 {
+		opts = append(opts, WithDropReportInterval(config.DropReportInterval))
+	}
+	if config.AfterClose != AfterCloseDiscard {
+		opts = append(opts, WithAfterClose(config.AfterClose))
+	}
+	if config.ErrorHandler != nil {
+		opts = append(opts, WithErrorHandler(config.ErrorHandler))
+	}
+	if config.SyncMode {
+		opts = append(opts, WithSyncMode())
+	}
+	if len(config.SyncOutputs) > 0 {
+		opts = append(opts, WithSyncOutput(config.SyncOutputs...))
+	}
+	if config.ReopenOnSignal {
+		opts = append(opts, WithReopenSignal(config.ReopenSignals...))
+	}
+	if len(config.ContextExtractors) > 0 {
+		opts = append(opts, WithContextExtractor(config.ContextExtractors...))
+	}
 	if config.FormatterRegistry.ConsoleFormatter != nil {
 		opts = append(opts, WithConsoleFormatter(config.FormatterRegistry.ConsoleFormatter))
 	}
@@ -0,0 +1,90 @@
+package log
+
+import (
+	"context"
+	"strings"
+)
+
+// ContextExtractor is a function that extracts fields from a context.
+// The extractors are called by the ...Ctx methods and the slog handler,
+// and the extracted fields are attached to the entry.
+type ContextExtractor func(ctx context.Context) []Field
+
+// ContextValueExtractor returns a ContextExtractor that attaches ctx.Value(key) as a field named fieldKey.
+// Nothing is attached when the value is nil.
+//
+// example:
+//
+//	type requestIDKey struct{}
+//	mlog, _ := log.NewLogger("my-app",
+//		log.WithContextExtractor(log.ContextValueExtractor(requestIDKey{}, "request_id")))
+//	...
+//	ctx = context.WithValue(ctx, requestIDKey{}, "abc-123")
+//	mlog.InfoCtx(ctx, "Hello, World!\n")
+//	// 13:04:05.000 [INFO] Hello, World! request_id=abc-123
+func ContextValueExtractor(key any, fieldKey string) ContextExtractor {
+	return func(ctx context.Context) []Field {
+		value := ctx.Value(key)
+		if value == nil {
+			return nil
+		}
+		return []Field{{Key: fieldKey, Value: value}}
+	}
+}
+
+// TraceParentExtractor returns a ContextExtractor that parses a W3C traceparent header value
+// stored in ctx.Value(key) and attaches trace_id and span_id fields.
+// The value must be a string in the form "00-<trace-id>-<parent-id>-<flags>".
+// Nothing is attached when the value is missing or malformed.
+func TraceParentExtractor(key any) ContextExtractor {
+	return func(ctx context.Context) []Field {
+		traceParent, ok := ctx.Value(key).(string)
+		if !ok {
+			return nil
+		}
+		traceID, spanID, ok := parseTraceParent(traceParent)
+		if !ok {
+			return nil
+		}
+		return []Field{{Key: "trace_id", Value: traceID}, {Key: "span_id", Value: spanID}}
+	}
+}
+
+// parseTraceParent parses a W3C traceparent value.
+// The version ff is invalid, and version 00 has exactly four parts. Later versions may append parts.
+// https://www.w3.org/TR/trace-context/#traceparent-header
+func parseTraceParent(traceParent string) (traceID, spanID string, ok bool) {
+	parts := strings.Split(strings.TrimSpace(traceParent), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || len(parts[1]) != 32 || len(parts[2]) != 16 || len(parts[3]) != 2 {
+		return "", "", false
+	}
+	if !isHex(parts[0]) || parts[0] == "ff" || (parts[0] == "00" && len(parts) != 4) {
+		return "", "", false
+	}
+	if !isHex(parts[1]) || !isHex(parts[2]) || !isHex(parts[3]) ||
+		parts[1] == strings.Repeat("0", 32) || parts[2] == strings.Repeat("0", 16) {
+		return "", "", false
+	}
+	return parts[1], parts[2], true
+}
+
+func isHex(s string) bool {
+	for _, c := range s {
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+// contextFields returns the fields extracted from the context by the registered extractors.
+func (l *logger) contextFields(ctx context.Context) []Field {
+	if ctx == nil {
+		return nil
+	}
+	var fields []Field
+	for _, extractor := range l.config.ContextExtractors {
+		fields = append(fields, extractor(ctx)...)
+	}
+	return fields
+}
@@ -4,187 +4,461 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
-	"os"
-	"path/filepath"
+	"slices"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
 // Writer is an interface that writes log entries to a specific output.
 // The Write method is called by the logger to write log entries.
+// fields holds the key-value pairs attached with With or the ...w methods.
+// Write is never called concurrently for the same output: an output is written by its own goroutine,
+// or under a lock by the logging goroutines when it is synchronous.
 type Writer interface {
-	Write(t time.Time, level LogLevel, format string, args ...any) (n int, err error)
+	Write(t time.Time, level LogLevel, fields []Field, format string, args ...any) (n int, err error)
 }
 
+// logEntry is shared by all outputs and must not be modified after it is queued.
 type logEntry struct {
-	t      time.Time
-	level  LogLevel
-	format string
-	args   []any
+	t         time.Time
+	level     LogLevel
+	threshold LogLevel // level of the logger, used by the outputs without their own level
+	fields    []Field
+	format    string
+	args      []any
+	op        func() // runs in the dispatcher goroutine in order with the entries instead of being written
 }
-type dynamicWriter struct {
-	wg      sync.WaitGroup
-	ctx     context.Context
-	cancel  context.CancelFunc
-	writers map[OutputMode]Writer
+
+// output is a named writer with its minimum level, its own queue and its own goroutine,
+// so a slow output does not delay the others. A full queue is handled by the backpressure policy, see queue.
+// NONE means the output uses the level of the logger that logged the entry.
+// A synchronous output has no queue and no goroutine and is written under mtx by the logging goroutines.
+type output struct {
+	name    string
+	mode    OutputMode // OutputModeCustom for the writers registered with WithWriter
+	writer  Writer
+	level   LogLevel
+	sync    bool
+	mtx     sync.Mutex // serializes the writes of a synchronous output
 	ch      chan *logEntry
+	pending chan *logEntry // ops taken out of ch by BackpressureDropOldest
+	wg      sync.WaitGroup
+	opWg    sync.WaitGroup // goroutines of sendOp
+	dropped atomic.Uint64
+
+	overflowMtx sync.Mutex
+	overflow    []*logEntry // entries that did not fit in ch under BackpressureBlock, written after ch
+}
+
+// The names of the built-in outputs. They cannot be used by the writers registered with WithWriter.
+const (
+	consoleOutputName = "console"
+	fileOutputName    = "file"
+	remoteOutputName  = "remote"
+)
+
+// dynamicWriter dispatches the entries of the shared queue to the queues of the outputs.
+//
+//	logf -> ch -> dispatcher goroutine -> output.ch -> output goroutine -> Writer
+//	logf -> Writer (synchronous outputs)
+type dynamicWriter struct {
+	wg        sync.WaitGroup
+	closeOnce sync.Once
+	closeErr  error
+	state     atomic.Int32
+	sendMtx   sync.RWMutex  // held for reading while sending to ch, and for writing while closing ch
+	syncMtx   sync.Mutex    // serializes the writes of AfterCloseSync
+	closed    chan struct{} // closed when the outputs are closed
+	mtx       sync.RWMutex  // guards writers; only the dispatcher goroutine modifies writers after run
+	writers   []*output
+	minLevel  atomic.Int32 // lowest level of the outputs with their own level, NONE if there is none
+	async     atomic.Bool  // whether any output is asynchronous, otherwise entries are not queued
+	ch        chan *logEntry
+	pending   chan *logEntry // ops taken out of ch by BackpressureDropOldest
+	size      int            // size of the queue of each output
+	policy    BackpressurePolicy
+	timeout   time.Duration
+	interval  time.Duration // interval of the dropped entries report
+	location  *time.Location
+	dropped   atomic.Uint64
+	reported  atomic.Uint64
+
+	congestion sync.Cond // signaled when no output has an overflow
+	congested  int       // number of outputs with an overflow, guarded by congestion.L
+
+	syncMode    bool            // every output is synchronous and there is no dispatcher goroutine
+	syncOutputs map[string]bool // names of the synchronous outputs when syncMode is false
+	opMtx       sync.Mutex      // serializes the ops in sync mode
+	stopSignals func()          // stops the reopen signal handler, nil if there is none
+
+	afterClosePolicy  AfterClosePolicy
+	fallbackFormatter Formatter
+
+	errorHandler       ErrorHandler
+	stderrErrorHandler stderrErrorHandler
 }
 
-func newDynamicWriter(l *logger) *dynamicWriter {
-	ctx, cancle := context.WithCancel(context.Background())
+func newDynamicWriter(l *logger) (*dynamicWriter, error) {
 	writer := &dynamicWriter{
-		ctx:     ctx,
-		cancel:  cancle,
-		writers: make(map[OutputMode]Writer),
-		ch:      make(chan *logEntry, l.config.EntrySize),
+		closed:   make(chan struct{}),
+		ch:       make(chan *logEntry, l.config.EntrySize),
+		pending:  make(chan *logEntry, l.config.EntrySize),
+		size:     l.config.EntrySize,
+		policy:   l.config.Backpressure,
+		timeout:  l.config.BackpressureTimeout,
+		interval: l.config.DropReportInterval,
+		location: l.config.Location,
+
+		syncMode:    l.config.SyncMode,
+		syncOutputs: make(map[string]bool, len(l.config.SyncOutputs)),
+
+		afterClosePolicy:  l.config.AfterClose,
+		fallbackFormatter: l.config.StandardFormatter,
+		errorHandler:      l.config.ErrorHandler,
+	}
+	writer.congestion.L = &sync.Mutex{}
+	if writer.interval <= 0 {
+		writer.interval = defaultDropReportInterval
+	}
+	for _, name := range l.config.SyncOutputs {
+		writer.syncOutputs[name] = true
+	}
+
+	// the file is opened before any output starts, so there is nothing to stop if it fails
+	var fileWriter Writer
+	if l.config.OutputMode&OutputModeFile != 0 {
+		var err error
+		if fileWriter, err = newFileWriter(l); err != nil {
+			return nil, err
+		}
 	}
 
 	if l.config.OutputMode&OutputModeConsole != 0 {
-		writer.writers[OutputModeConsole] = newConsoleWriter(l)
+		writer.addOutput(consoleOutputName, newConsoleWriter(l), l.config.ConsoleConfig.Level)
 	}
 
 	if l.config.OutputMode&OutputModeFile != 0 {
-		writer.writers[OutputModeFile] = newFileWriter(l)
+		writer.addOutput(fileOutputName, fileWriter, l.config.FileConfig.Level)
 	}
 
 	if l.config.OutputMode&OutputModeRemote != 0 {
-		writer.writers[OutputModeRemote] = newRemoteWriter(l)
+		writer.addOutput(remoteOutputName, newRemoteWriter(l), l.config.RemoteConfig.Level)
+	}
+
+	for _, w := range l.config.Writers {
+		writer.addOutput(w.Name, newCustomWriter(w.Writer, w.Formatter), NONE)
 	}
 
-	return writer
+	return writer, nil
 }
 
+// addOutput adds the output and starts its goroutine unless the output is synchronous.
+func (d *dynamicWriter) addOutput(name string, writer Writer, level LogLevel) {
+	mode, ok := outputModes[name]
+	if !ok {
+		mode = OutputModeCustom
+	}
+	output := &output{name: name, mode: mode, writer: writer, level: level, sync: d.syncMode || d.syncOutputs[name]}
+	if reporter, ok := writer.(errorReporter); ok {
+		reporter.setErrorHandler(func(err error) { d.handleError(output, err) })
+	}
+	if !output.sync {
+		output.ch = make(chan *logEntry, d.size)
+		output.pending = make(chan *logEntry, d.size)
+		output.run(d)
+	}
+	d.mtx.Lock()
+	d.writers = append(d.writers, output)
+	d.updateOutputs()
+	d.mtx.Unlock()
+}
+
+// removeOutput removes the output with the name and returns it, or nil if there is none.
+// The output is still running and must be stopped by the caller.
+func (d *dynamicWriter) removeOutput(name string) *output {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+	for i, output := range d.writers {
+		if output.name == name {
+			d.writers = append(d.writers[:i:i], d.writers[i+1:]...)
+			d.updateOutputs()
+			return output
+		}
+	}
+	return nil
+}
+
+func (d *dynamicWriter) hasOutput(name string) bool {
+	for _, output := range d.writers {
+		if output.name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// updateOutputs updates minLevel and async after the outputs change. d.mtx must be held.
+func (d *dynamicWriter) updateOutputs() {
+	minLevel := NONE
+	async := false
+	for _, output := range d.writers {
+		if output.level != NONE && (minLevel == NONE || output.level < minLevel) {
+			minLevel = output.level
+		}
+		async = async || !output.sync
+	}
+	d.minLevel.Store(int32(minLevel))
+	d.async.Store(async)
+}
+
+// minLevelFor returns the lowest level written by any output for a logger with the level.
+func (d *dynamicWriter) minLevelFor(level LogLevel) LogLevel {
+	if minLevel := LogLevel(d.minLevel.Load()); minLevel != NONE && minLevel < level {
+		return minLevel
+	}
+	return level
+}
+
+// do runs op in the dispatcher goroutine after the entries queued before it are dispatched,
+// and returns the error of op. In sync mode op runs in the calling goroutine.
+func (d *dynamicWriter) do(op func() error) error {
+	if d.syncMode {
+		return d.doSync(op)
+	}
+	var err error
+	done := make(chan struct{})
+	if err := d.sendOp(context.Background(), &logEntry{op: func() {
+		err = op()
+		close(done)
+	}}); err != nil {
+		return err
+	}
+	<-done
+	return err
+}
+
+// queueDepth returns the number of entries waiting in the shared queue and in the queue of each output.
+func (d *dynamicWriter) queueDepth() map[string]int {
+	d.mtx.RLock()
+	defer d.mtx.RUnlock()
+	depth := make(map[string]int, len(d.writers)+1)
+	depth[""] = len(d.ch)
+	for _, output := range d.writers {
+		output.overflowMtx.Lock()
+		depth[output.name] = len(output.ch) + len(output.pending) + len(output.overflow)
+		output.overflowMtx.Unlock()
+	}
+	return depth
+}
+
+// run starts the dispatcher goroutine. There is none in sync mode.
 func (d *dynamicWriter) run() {
+	if d.syncMode {
+		return
+	}
 	d.wg.Add(1)
 	go func() {
 		defer d.wg.Done()
+		ticker := time.NewTicker(d.interval)
+		defer ticker.Stop()
 		for {
 			select {
 			case logEntry, ok := <-d.ch:
 				if !ok {
 					return
 				}
-				d.writer(logEntry.t, logEntry.level, logEntry.format, logEntry.args...)
-			case <-d.ctx.Done():
-				return
+				d.runPending()
+				d.process(logEntry)
+			case logEntry := <-d.pending:
+				d.process(logEntry)
+			case <-ticker.C:
+				d.reportDropped(d.policy)
 			}
 		}
 	}()
 }
 
-func (d *dynamicWriter) writer(t time.Time, level LogLevel, format string, args ...any) {
-	for _, writer := range d.writers {
-		_, _ = writer.Write(t, level, format, args...)
+func (d *dynamicWriter) process(entry *logEntry) {
+	if entry.op != nil {
+		entry.op()
+		return
 	}
+	d.dispatch(entry)
 }
 
-func (d *dynamicWriter) close() {
-	d.cancel()
-	d.wg.Wait()
-	close(d.ch)
-
-	for entry := range d.ch {
-		d.writer(entry.t, entry.level, entry.format, entry.args...)
-	}
+// dispatch queues the entry to the asynchronous outputs whose level allows it.
+// A full output queue is handled by the backpressure policy of the logger, see queue.
+func (d *dynamicWriter) dispatch(entry *logEntry) {
+	d.dispatchWith(entry, d.policy)
+}
 
-	for _, writer := range d.writers {
-		if f, ok := writer.(*fileWriter); ok {
-			_ = f.file.Close()
+func (d *dynamicWriter) dispatchWith(entry *logEntry, policy BackpressurePolicy) {
+	for _, output := range d.writers {
+		if output.sync || !output.accepts(entry) {
+			continue
+		}
+		if dropped := d.queue(output, entry, policy); dropped > 0 {
+			output.dropped.Add(dropped)
+			d.dropped.Add(dropped)
 		}
 	}
-
 }
 
-type consoleWriter struct {
-	formatter Formatter
-	writer    io.Writer
+// close writes the remaining entries, stops the outputs and closes the writers that implement io.Closer.
+// It returns the errors of closing the writers. It is safe to call close more than once.
+// Entries logged after close is called are handled by the after close policy.
+func (d *dynamicWriter) close() error {
+	d.closeOnce.Do(func() {
+		if d.stopSignals != nil {
+			d.stopSignals()
+		}
+		d.state.Store(stateClosing)
+		// wait for the senders that are already sending, then the dispatcher drains ch and returns
+		d.sendMtx.Lock()
+		close(d.ch)
+		d.sendMtx.Unlock()
+		d.wg.Wait()
+
+		d.runPending()
+		d.reportDropped(BackpressureBlock)
+
+		var errs []error
+		for _, output := range d.writers {
+			if err := output.stop(); err != nil {
+				errs = append(errs, fmt.Errorf("output %q: %w", output.name, err))
+			}
+		}
+		d.closeErr = errors.Join(errs...)
+		d.state.Store(stateClosed)
+		close(d.closed)
+	})
+	return d.closeErr
 }
 
-func newConsoleWriter(l *logger) Writer {
-	return &consoleWriter{
-		formatter: l.config.FormatterRegistry.ConsoleFormatter,
-		writer:    l.config.ConsoleConfig.Writer,
+// accepts reports whether the level of the output allows the entry.
+func (o *output) accepts(entry *logEntry) bool {
+	level := o.level
+	if level == NONE {
+		level = entry.threshold
 	}
+	return entry.level >= level
 }
 
-func (c *consoleWriter) Write(t time.Time, level LogLevel, format string, args ...any) (n int, err error) {
-	return fmt.Fprint(c.writer, c.formatter(t, level, format, args...))
+func (o *output) run(d *dynamicWriter) {
+	o.wg.Add(1)
+	go func() {
+		defer o.wg.Done()
+		for {
+			select {
+			case entry, ok := <-o.ch:
+				if !ok {
+					o.runPending(d)
+					o.runOverflow(d)
+					return
+				}
+				o.runPending(d)
+				o.process(d, entry)
+			case entry := <-o.pending:
+				o.process(d, entry)
+			}
+			o.runOverflow(d)
+		}
+	}()
 }
 
-type fileWriter struct {
-	name            string
-	logPath         string
-	mode            FileCreateMode
-	formatter       Formatter
-	currentFileName string
-	file            *os.File
+// runOverflow writes the overflow of the output once ch is empty, so the overflow is written after ch.
+func (o *output) runOverflow(d *dynamicWriter) {
+	for _, entry := range d.takeOverflow(o) {
+		o.process(d, entry)
+	}
 }
 
-func newFileWriter(l *logger) Writer {
-	// logPath dir를 생성
-
-	var logPath string
-
-	path := os.ExpandEnv(l.config.FileConfig.LogPath)
-
-	if filepath.IsAbs(path) {
-		logPath = path
-	} else {
-		var err error
-		logPath, err = filepath.Abs(path)
-		if err != nil {
-			logPath = fmt.Sprintf("%s/%s", os.Getenv("HOME"), path)
+// runPending runs the ops taken out of the queue of the output by BackpressureDropOldest.
+func (o *output) runPending(d *dynamicWriter) {
+	for {
+		select {
+		case entry := <-o.pending:
+			o.process(d, entry)
+		default:
+			return
 		}
 	}
+}
 
-	if _, err := os.Stat(logPath); os.IsNotExist(err) {
-		_ = os.MkdirAll(logPath, 0755)
+func (o *output) process(d *dynamicWriter, entry *logEntry) {
+	if entry.op != nil {
+		entry.op()
+		return
 	}
-
-	return &fileWriter{
-		name:      l.name,
-		logPath:   logPath,
-		mode:      l.config.FileConfig.FileCreateMode,
-		formatter: l.config.FormatterRegistry.FileFormmater,
+	if _, err := o.writer.Write(entry.t, entry.level, entry.fields, entry.format, entry.args...); err != nil {
+		d.handleError(o, err)
 	}
 }
 
-func (f *fileWriter) generatedFileName(t time.Time) string {
-	if f.mode == DAILYMODE {
-		return fmt.Sprintf("%s/%s.%s.log", f.logPath, f.name, t.Format(time.DateOnly))
+// sendOp queues the op to the output from its own goroutine, so the dispatcher goroutine never waits
+// for a full queue of a stuck output. The op is queued after the entries dispatched before it,
+// so it is appended to the overflow if the output has one.
+func (o *output) sendOp(op *logEntry) {
+	o.overflowMtx.Lock()
+	if len(o.overflow) > 0 {
+		o.overflow = append(o.overflow, op)
+		o.overflowMtx.Unlock()
+		return
 	}
-	return fmt.Sprintf("%s/%s.%s.log", f.logPath, f.name, t.Format("2006-01-02-15"))
+	o.overflowMtx.Unlock()
+	o.opWg.Add(1)
+	go func() {
+		defer o.opWg.Done()
+		o.ch <- op
+	}()
 }
 
-func (f *fileWriter) Write(t time.Time, level LogLevel, format string, args ...any) (n int, err error) {
-	generatedFileName := f.generatedFileName(t)
-	if generatedFileName != f.currentFileName {
-		f.currentFileName = generatedFileName
-		if f.file != nil {
-			_ = f.file.Close()
-		}
-		f.file, err = os.OpenFile(f.currentFileName, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
-		if err != nil {
-			return 0, err
-		}
+// stop writes the queued entries and closes the writer if it implements io.Closer.
+func (o *output) stop() error {
+	if o.sync {
+		o.mtx.Lock()
+		defer o.mtx.Unlock()
+	} else {
+		o.opWg.Wait()
+		close(o.ch)
+		o.wg.Wait()
 	}
+	if c, ok := o.writer.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
 
-	if f.file != nil {
-		return fmt.Fprint(f.file, f.formatter(t, level, format, args...))
+type consoleWriter struct {
+	formatter Formatter
+	writer    io.Writer
+}
+
+func newConsoleWriter(l *logger) Writer {
+	return &consoleWriter{
+		formatter: l.config.FormatterRegistry.ConsoleFormatter,
+		writer:    l.config.ConsoleConfig.Writer,
 	}
+}
 
-	return 0, nil
+func (c *consoleWriter) Write(t time.Time, level LogLevel, fields []Field, format string, args ...any) (n int, err error) {
+	return fmt.Fprint(c.writer, c.formatter(t, level, fields, format, args...))
 }
 
 type remoteWriter struct {
-	endpoint  string
-	method    string
-	header    http.Header
-	transport *http.RoundTripper
-	formatter Formatter
+	wg           sync.WaitGroup
+	sync         bool // send the request in Write instead of in the background
+	endpoint     string
+	method       string
+	header       http.Header
+	transport    *http.RoundTripper
+	formatter    Formatter
+	errorHandler func(err error)
 }
 
 func newRemoteWriter(l *logger) Writer {
@@ -194,51 +468,134 @@ func newRemoteWriter(l *logger) Writer {
 		header:    l.config.RemoteConfig.Header,
 		transport: l.config.RemoteConfig.Transport,
 		formatter: l.config.FormatterRegistry.RemoteFormatter,
+		sync:      l.config.SyncMode || slices.Contains(l.config.SyncOutputs, remoteOutputName),
 	}
 }
 
-func (r *remoteWriter) Write(t time.Time, level LogLevel, format string, args ...any) (n int, err error) {
-	var client *http.Client
-	type remoteLog struct {
-		Time    time.Time `json:"time"`
-		Level   string    `json:"level"`
-		Message string    `json:"message"`
+func (r *remoteWriter) Write(t time.Time, level LogLevel, fields []Field, format string, args ...any) (n int, err error) {
+	// fields are emitted as top-level JSON properties.
+	// time, level and message are reserved and cannot be overwritten by a field.
+	var log = make(map[string]any, len(fields)+3)
+	for _, field := range fields {
+		if err, ok := field.Value.(error); ok {
+			log[field.Key] = err.Error()
+			continue
+		}
+		log[field.Key] = field.Value
 	}
+	log["time"] = t
+	log["level"] = LoglevelNames[level]
+	log["message"] = fmt.Sprintf(format, args...)
 
-	var log = &remoteLog{
-		Time:    t,
-		Level:   LoglevelNames[level],
-		Message: fmt.Sprintf(format, args...),
+	if r.sync {
+		return 0, r.post(log)
 	}
-
+	r.wg.Add(1)
 	go func() {
-		dat, err := json.Marshal(log)
-		if err != nil {
-			return
+		defer r.wg.Done()
+		if err := r.post(log); err != nil {
+			r.handleError(err)
 		}
+	}()
+	return 0, nil
+}
 
-		buffer := bytes.NewBuffer(dat)
+// post sends the entry to the endpoint.
+func (r *remoteWriter) post(log map[string]any) error {
+	var client *http.Client
 
-		req, err := http.NewRequest(r.method, r.endpoint, buffer)
-		if err != nil {
-			return
-		}
+	dat, err := json.Marshal(log)
+	if err != nil {
+		return err
+	}
 
-		if (r.header != nil) && (len(r.header) > 0) {
-			req.Header = r.header
-		}
+	buffer := bytes.NewBuffer(dat)
 
-		req.Header.Set("Content-Type", "application/json")
-		if r.transport != nil {
-			client = &http.Client{Transport: *r.transport}
-		} else {
-			client = http.DefaultClient
-		}
+	req, err := http.NewRequest(r.method, r.endpoint, buffer)
+	if err != nil {
+		return err
+	}
 
-		_, err = client.Do(req)
-		if err != nil {
-			return
-		}
-	}()
-	return 0, nil
+	if (r.header != nil) && (len(r.header) > 0) {
+		req.Header = r.header.Clone()
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	if r.transport != nil {
+		client = &http.Client{Transport: *r.transport}
+	} else {
+		client = http.DefaultClient
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	_ = resp.Body.Close()
+	if resp.StatusCode >= http.StatusBadRequest {
+		return fmt.Errorf("%w: %s", ErrRemoteStatus, resp.Status)
+	}
+	return nil
+}
+
+func (r *remoteWriter) setErrorHandler(handler func(err error)) {
+	r.errorHandler = handler
+}
+
+func (r *remoteWriter) handleError(err error) {
+	if r.errorHandler != nil {
+		r.errorHandler(err)
+	}
+}
+
+// Flush waits for the in-flight requests to finish.
+func (r *remoteWriter) Flush() error {
+	r.wg.Wait()
+	return nil
+}
+
+// Close waits for the in-flight requests to finish.
+func (r *remoteWriter) Close() error {
+	r.wg.Wait()
+	return nil
+}
+
+// formattedWriter is a writer registered with a formatter.
+// It passes the formatted entry to the writer as a single "%s" argument.
+type formattedWriter struct {
+	writer    Writer
+	formatter Formatter
+}
+
+func newCustomWriter(w Writer, formatter Formatter) Writer {
+	if formatter == nil {
+		return w
+	}
+	return &formattedWriter{writer: w, formatter: formatter}
+}
+
+func (f *formattedWriter) Write(t time.Time, level LogLevel, fields []Field, format string, args ...any) (n int, err error) {
+	return f.writer.Write(t, level, fields, "%s", f.formatter(t, level, fields, format, args...))
+}
+
+func (f *formattedWriter) Flush() error {
+	if flusher, ok := f.writer.(flusher); ok {
+		return flusher.Flush()
+	}
+	return nil
+}
+
+func (f *formattedWriter) Reopen() error {
+	if r, ok := f.writer.(reopener); ok {
+		return r.Reopen()
+	}
+	return nil
+}
+
+func (f *formattedWriter) Close() error {
+	if c, ok := f.writer.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
 }
@@ -0,0 +1,70 @@
+package log
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// ErrorHandler is called when an output fails to write an entry,
+// e.g. when the disk is full or the remote endpoint rejects the entry.
+// err is wrapped with the name of the output. output is OutputModeCustom for the writers registered with WithWriter.
+// The handler is called from the goroutines of the outputs and must be safe for concurrent use.
+type ErrorHandler func(output OutputMode, err error)
+
+// errorReportInterval is the minimum interval between two errors of the same output written to stderr
+// when no ErrorHandler is set.
+const errorReportInterval = 10 * time.Second
+
+// errorReporter is implemented by writers that fail outside of Write, e.g. in a background request.
+type errorReporter interface {
+	setErrorHandler(handler func(err error))
+}
+
+// stderrErrorHandler writes the errors of the outputs to stderr,
+// at most one per output every errorReportInterval, and counts the suppressed errors.
+type stderrErrorHandler struct {
+	mtx     sync.Mutex
+	reports map[string]*errorReport
+}
+
+type errorReport struct {
+	last       time.Time
+	suppressed int
+}
+
+func (s *stderrErrorHandler) handle(name string, err error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	if s.reports == nil {
+		s.reports = make(map[string]*errorReport)
+	}
+	report, ok := s.reports[name]
+	if !ok {
+		report = &errorReport{}
+		s.reports[name] = report
+	}
+	now := time.Now()
+	if now.Sub(report.last) < errorReportInterval {
+		report.suppressed++
+		return
+	}
+	if report.suppressed > 0 {
+		fmt.Fprintf(os.Stderr, "go-log: %v (%d similar errors suppressed)\n", err, report.suppressed)
+	} else {
+		fmt.Fprintf(os.Stderr, "go-log: %v\n", err)
+	}
+	report.last = now
+	report.suppressed = 0
+}
+
+// handleError reports the error of the output to the ErrorHandler, or to stderr if there is none.
+func (d *dynamicWriter) handleError(o *output, err error) {
+	err = fmt.Errorf("output %q: %w", o.name, err)
+	if d.errorHandler != nil {
+		d.errorHandler(o.mode, err)
+		return
+	}
+	d.stderrErrorHandler.handle(o.name, err)
+}
@@ -1,8 +1,26 @@
 package log
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+)
 
 var (
 	ErrRemoteConfig   = errors.New("config is required in remote mode")
 	ErrRemoteEndpoint = errors.New("endpoint is required")
+	ErrInvalidVModule = errors.New("invalid vmodule pattern")
+	ErrWriterName     = errors.New("writer name is empty or reserved")
+	ErrNilWriter      = errors.New("writer is nil")
+	ErrOutputExists   = errors.New("output already exists")
+	ErrOutputNotFound = errors.New("output not found")
+	ErrClosed         = errors.New("logger is closed")
+	ErrRemoteStatus   = errors.New("remote endpoint rejected the entry")
+	ErrFileInterval   = errors.New("file interval of a day or more must be whole days")
+
+	// ErrLogPath is returned by NewLogger and EnableFileMode when the log path cannot be used.
+	// The errors below wrap it, and the error returned wraps the error of the file system as well.
+	ErrLogPath           = errors.New("invalid log path")
+	ErrLogPathNotFound   = fmt.Errorf("%w: path not found", ErrLogPath)
+	ErrLogPathPermission = fmt.Errorf("%w: permission denied", ErrLogPath)
+	ErrLogPathReadOnly   = fmt.Errorf("%w: read-only file system", ErrLogPath)
 )
@@ -0,0 +1,80 @@
+package log
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Field is a key-value pair attached to a log entry.
+// Fields are passed to every Formatter and Writer alongside the message.
+type Field struct {
+	Key   string
+	Value any
+}
+
+// badKey is the key used when a value has no matching key.
+const badKey = "!BADKEY"
+
+// Any creates a field with the key and value.
+func Any(key string, value any) Field {
+	return Field{Key: key, Value: value}
+}
+
+// toFields converts alternating key-value pairs to fields.
+// A Field can be passed as a single element. A key that is not a string is
+// converted with fmt.Sprint, and a trailing value without a key is stored under "!BADKEY".
+func toFields(keysAndValues []any) []Field {
+	if len(keysAndValues) == 0 {
+		return nil
+	}
+	fields := make([]Field, 0, (len(keysAndValues)+1)/2)
+	for i := 0; i < len(keysAndValues); i++ {
+		if field, ok := keysAndValues[i].(Field); ok {
+			fields = append(fields, field)
+			continue
+		}
+		if i+1 == len(keysAndValues) {
+			fields = append(fields, Field{Key: badKey, Value: keysAndValues[i]})
+			break
+		}
+		key, ok := keysAndValues[i].(string)
+		if !ok {
+			key = fmt.Sprint(keysAndValues[i])
+		}
+		fields = append(fields, Field{Key: key, Value: keysAndValues[i+1]})
+		i++
+	}
+	return fields
+}
+
+// mergeFields returns a new slice that contains base followed by extra.
+// base is never modified, so it can be shared between child loggers.
+func mergeFields(base, extra []Field) []Field {
+	if len(extra) == 0 {
+		return base
+	}
+	if len(base) == 0 {
+		return extra
+	}
+	fields := make([]Field, 0, len(base)+len(extra))
+	fields = append(fields, base...)
+	return append(fields, extra...)
+}
+
+// writeFields writes fields in logfmt style (key=value) to the builder.
+func writeFields(sb *strings.Builder, fields []Field) {
+	for _, field := range fields {
+		sb.WriteByte(' ')
+		sb.WriteString(field.Key)
+		sb.WriteByte('=')
+		writeValue(sb, fmt.Sprint(field.Value))
+	}
+}
+
+func writeValue(sb *strings.Builder, value string) {
+	if value == "" || strings.ContainsAny(value, " =\"\t\r\n") {
+		fmt.Fprintf(sb, "%q", value)
+		return
+	}
+	sb.WriteString(value)
+}
@@ -0,0 +1,82 @@
+package log
+
+import (
+	"time"
+)
+
+// FsyncPolicy decides when the log file is synced to the disk with fsync.
+type FsyncPolicy int
+
+const (
+	// FsyncNever leaves syncing to the operating system. It is the default.
+	FsyncNever FsyncPolicy = iota
+	// FsyncInterval syncs the file every flush interval.
+	FsyncInterval
+	// FsyncError syncs the file after every entry at or above ERROR.
+	FsyncError
+)
+
+// defaultFlushInterval is the flush interval of the file when FileConfig.FlushInterval is not set.
+const defaultFlushInterval = time.Second
+
+// flushLocked writes the buffered entries to the file and syncs the file if sync is true. f.mtx must be held.
+func (f *fileWriter) flushLocked(sync bool) error {
+	if f.file == nil {
+		return nil
+	}
+	if f.buf != nil {
+		if err := f.buf.Flush(); err != nil {
+			return err
+		}
+	}
+	if sync {
+		return f.file.Sync()
+	}
+	return nil
+}
+
+// Flush writes the buffered entries to the file, and syncs the file unless the fsync policy is FsyncNever.
+func (f *fileWriter) Flush() error {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	return f.flushLocked(f.fsync != FsyncNever)
+}
+
+// startFlusher starts the goroutine that flushes the buffer, and syncs the file for FsyncInterval,
+// every flush interval. It does nothing if the goroutine is running or not needed.
+func (f *fileWriter) startFlusher() {
+	if f.stopFlusher != nil || (f.bufferSize <= 0 && f.fsync != FsyncInterval) {
+		return
+	}
+	stop := make(chan struct{})
+	f.stopFlusher = stop
+	f.flusherWg.Add(1)
+	go func() {
+		defer f.flusherWg.Done()
+		ticker := time.NewTicker(f.flushInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				f.mtx.Lock()
+				err := f.flushLocked(f.fsync == FsyncInterval)
+				f.mtx.Unlock()
+				if err != nil {
+					f.handleError(err)
+				}
+			case <-stop:
+				return
+			}
+		}
+	}()
+}
+
+// stopFlushing stops the goroutine started by startFlusher. f.mtx must not be held.
+func (f *fileWriter) stopFlushing() {
+	if f.stopFlusher == nil {
+		return
+	}
+	close(f.stopFlusher)
+	f.flusherWg.Wait()
+	f.stopFlusher = nil
+}
@@ -0,0 +1,202 @@
+package log
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// The file name patterns of the file create modes.
+const (
+	dailyFilePattern    = "{name}.%Y-%m-%d.log"
+	hourlyFilePattern   = "{name}.%Y-%m-%d-%H.log"
+	intervalFilePattern = "{name}.%Y-%m-%d-%H%M.log" // used when only FileConfig.Interval is set
+)
+
+// fileNamePattern generates the file names of a fileWriter from a pattern such as "{name}-{host}-%Y%m%d-%H%M.log".
+//
+// The placeholders are {name} (the name of the logger), {host} (the host name), {pid} (the process id)
+// and {level} (the level of the file output). The time directives are
+// %Y, %y, %m, %d, %H, %M, %S, %j (day of the year), %G and %V (ISO year and week), %u (ISO weekday) and %%.
+type fileNamePattern struct {
+	pattern  string
+	values   map[string]string
+	interval time.Duration
+	regexp   *regexp.Regexp // matches the file names of every period, with or without a segment and .gz
+	anyPid   *regexp.Regexp // like regexp, but matches the files of any pid for {pid}
+}
+
+func newFileNamePattern(pattern string, interval time.Duration, values map[string]string) *fileNamePattern {
+	if !strings.HasSuffix(pattern, ".log") {
+		pattern += ".log"
+	}
+	p := &fileNamePattern{pattern: pattern, values: values, interval: interval}
+	p.regexp = regexp.MustCompile("^" + p.expand(nil, false) + `(\.[1-9][0-9]*)?\.log(\.gz)?$`)
+	p.anyPid = regexp.MustCompile("^" + p.expand(nil, true) + `(\.[1-9][0-9]*)?\.log(\.gz)?$`)
+	return p
+}
+
+// fileNameValues returns the values of the placeholders for the logger.
+func fileNameValues(l *logger) map[string]string {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "localhost"
+	}
+	level := l.config.FileConfig.Level
+	if level == NONE {
+		level = l.config.Level
+	}
+	return map[string]string{
+		"name":  l.name,
+		"host":  host,
+		"pid":   strconv.Itoa(os.Getpid()),
+		"level": LoglevelNames[level],
+	}
+}
+
+// period returns the start of the period that contains t.
+func (p *fileNamePattern) period(t time.Time) time.Time {
+	return periodStart(t, p.interval)
+}
+
+// format returns the file name of the period that starts at start.
+func (p *fileNamePattern) format(start time.Time) string {
+	return p.expand(&start, false) + ".log"
+}
+
+// matches reports whether the base name is a file generated by the pattern for the pid of the process.
+func (p *fileNamePattern) matches(name string) bool {
+	return p.regexp.MatchString(name)
+}
+
+// matchesAnyPid reports whether the base name is a file generated by the pattern for any pid.
+// It is the same as matches if the pattern has no {pid}.
+func (p *fileNamePattern) matchesAnyPid(name string) bool {
+	return p.anyPid.MatchString(name)
+}
+
+// expand replaces the placeholders and the directives of the pattern without the .log extension.
+// If t is nil, it returns a regular expression that matches any time instead,
+// and any pid for {pid} if anyPid is set.
+func (p *fileNamePattern) expand(t *time.Time, anyPid bool) string {
+	var sb strings.Builder
+	pattern := strings.TrimSuffix(p.pattern, ".log")
+	literal := func(s string) {
+		if t == nil {
+			s = regexp.QuoteMeta(s)
+		}
+		sb.WriteString(s)
+	}
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; {
+		case c == '{':
+			end := strings.IndexByte(pattern[i:], '}')
+			if end < 0 {
+				literal(pattern[i:])
+				return sb.String()
+			}
+			key := pattern[i+1 : i+end]
+			value, ok := p.values[key]
+			switch {
+			case !ok:
+				literal(pattern[i : i+end+1])
+			case t == nil && anyPid && key == "pid":
+				sb.WriteString(`[0-9]+`)
+			default:
+				literal(value)
+			}
+			i += end
+		case c == '%' && i+1 < len(pattern):
+			i++
+			if t == nil {
+				sb.WriteString(directiveRegexp(pattern[i]))
+			} else {
+				sb.WriteString(formatDirective(*t, pattern[i]))
+			}
+		default:
+			literal(string(c))
+		}
+	}
+	return sb.String()
+}
+
+func formatDirective(t time.Time, directive byte) string {
+	switch directive {
+	case 'Y':
+		return fmt.Sprintf("%04d", t.Year())
+	case 'y':
+		return fmt.Sprintf("%02d", t.Year()%100)
+	case 'm':
+		return fmt.Sprintf("%02d", t.Month())
+	case 'd':
+		return fmt.Sprintf("%02d", t.Day())
+	case 'H':
+		return fmt.Sprintf("%02d", t.Hour())
+	case 'M':
+		return fmt.Sprintf("%02d", t.Minute())
+	case 'S':
+		return fmt.Sprintf("%02d", t.Second())
+	case 'j':
+		return fmt.Sprintf("%03d", t.YearDay())
+	case 'G':
+		year, _ := t.ISOWeek()
+		return fmt.Sprintf("%04d", year)
+	case 'V':
+		_, week := t.ISOWeek()
+		return fmt.Sprintf("%02d", week)
+	case 'u':
+		return strconv.Itoa((int(t.Weekday())+6)%7 + 1)
+	case '%':
+		return "%"
+	}
+	return "%" + string(directive)
+}
+
+func directiveRegexp(directive byte) string {
+	switch directive {
+	case 'Y', 'G':
+		return `[0-9]{4}`
+	case 'y', 'm', 'd', 'H', 'M', 'S', 'V':
+		return `[0-9]{2}`
+	case 'j':
+		return `[0-9]{3}`
+	case 'u':
+		return `[1-7]`
+	case '%':
+		return "%"
+	}
+	return regexp.QuoteMeta("%" + string(directive))
+}
+
+// validInterval reports whether the interval can be used for the periods of the log files:
+// an interval shorter than a day, or whole days.
+func validInterval(interval time.Duration) bool {
+	const day = 24 * time.Hour
+	return interval > 0 && (interval < day || interval%day == 0)
+}
+
+// periodReference is the start of the periods of a day or longer. It is a Monday, so weekly periods start on Monday.
+var periodReference = time.Date(2001, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// periodStart returns the start of the period of the interval that contains t, in the location of t.
+// Periods shorter than a day start at the midnight of the day, e.g. every 15 minutes from 00:00,
+// and periods of whole days start at the midnight of every interval days from a Monday.
+// An interval of a day or more must be whole days, see validInterval.
+// The boundaries follow the wall clock of the location.
+func periodStart(t time.Time, interval time.Duration) time.Time {
+	const day = 24 * time.Hour
+	year, month, date := t.Date()
+	if interval < day {
+		hour, minute, second := t.Clock()
+		elapsed := time.Duration(hour)*time.Hour + time.Duration(minute)*time.Minute +
+			time.Duration(second)*time.Second + time.Duration(t.Nanosecond())
+		return time.Date(year, month, date, 0, 0, 0, int(elapsed-elapsed%interval), t.Location())
+	}
+	days := int(interval / day)
+	n := int(time.Date(year, month, date, 0, 0, 0, 0, time.UTC).Sub(periodReference) / day)
+	n -= ((n % days) + days) % days
+	return time.Date(2001, time.January, 1+n, 0, 0, 0, 0, t.Location())
+}
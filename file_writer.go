@@ -0,0 +1,318 @@
+package log
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// fileWriter writes the entries to a file that changes every day or hour, or every FileConfig.Interval,
+// and is named by FileConfig.Pattern.
+// With FileConfig.MaxSize, a file that reaches the size is continued in numbered segments:
+//
+//	name.2024-01-01.log -> name.2024-01-01.1.log -> name.2024-01-01.2.log ...
+//
+// With FileConfig.Compress, the previous files are compressed to name.2024-01-01.log.gz by the janitor.
+// The symlink name.log points at the file being written unless FileConfig.DisableSymlink is set.
+// With FileConfig.BufferSize, the entries are buffered and flushed every FileConfig.FlushInterval,
+// on rotation, on Close and after every entry at or above ERROR.
+type fileWriter struct {
+	mtx             sync.Mutex // guards the file and the buffer against the flusher goroutine
+	logPath         string
+	pattern         *fileNamePattern
+	symlink         string // path of the symlink to the active file, empty if disabled
+	maxSize         int64
+	formatter       Formatter
+	period          time.Time // start of the current period
+	baseFileName    string    // file name of the current period without the segment number
+	segment         int
+	currentFileName string
+	size            int64 // size of the current file, tracked by Write
+	file            *os.File
+	info            os.FileInfo   // of the file when it was opened, to detect that it was moved or removed
+	checked         time.Time     // last time the file was checked by checkFile
+	buf             *bufio.Writer // nil if the file is not buffered
+	bufferSize      int
+	flushInterval   time.Duration
+	fsync           FsyncPolicy
+	stopFlusher     chan struct{}
+	flusherWg       sync.WaitGroup
+	maxAge          time.Duration
+	maxFiles        int
+	compress        bool
+	recovered       bool // whether the files of an interrupted compression have been cleaned up
+	janitor         janitor
+	errorHandler    func(err error)
+}
+
+// newFileWriter creates the log path and opens the first log file to make sure they can be written.
+// It returns an error that wraps ErrLogPath if they cannot.
+func newFileWriter(l *logger) (Writer, error) {
+	var logPath string
+
+	path := os.ExpandEnv(l.config.FileConfig.LogPath)
+
+	if filepath.IsAbs(path) {
+		logPath = path
+	} else {
+		var err error
+		logPath, err = filepath.Abs(path)
+		if err != nil {
+			logPath = fmt.Sprintf("%s/%s", os.Getenv("HOME"), path)
+		}
+	}
+
+	pattern, interval := l.config.FileConfig.Pattern, l.config.FileConfig.Interval
+	switch {
+	case interval <= 0 && l.config.FileConfig.FileCreateMode == HOURLYMODE:
+		interval = time.Hour
+	case interval <= 0:
+		interval = 24 * time.Hour
+	case !validInterval(interval):
+		return nil, fmt.Errorf("%w: %s", ErrFileInterval, interval)
+	}
+	if pattern == "" {
+		switch {
+		case l.config.FileConfig.Interval > 0:
+			pattern = intervalFilePattern
+		case l.config.FileConfig.FileCreateMode == HOURLYMODE:
+			pattern = hourlyFilePattern
+		default:
+			pattern = dailyFilePattern
+		}
+	}
+
+	var symlink string
+	if !l.config.FileConfig.DisableSymlink {
+		symlink = filepath.Join(logPath, l.name+".log")
+	}
+
+	flushInterval := l.config.FileConfig.FlushInterval
+	if flushInterval <= 0 {
+		flushInterval = defaultFlushInterval
+	}
+
+	f := &fileWriter{
+		bufferSize:    l.config.FileConfig.BufferSize,
+		flushInterval: flushInterval,
+		fsync:         l.config.FileConfig.Fsync,
+		symlink:       symlink,
+		logPath:       logPath,
+		pattern:       newFileNamePattern(pattern, interval, fileNameValues(l)),
+		maxSize:       l.config.FileConfig.MaxSize,
+		maxAge:        l.config.FileConfig.MaxAge,
+		maxFiles:      l.config.FileConfig.MaxFiles,
+		compress:      l.config.FileConfig.Compress,
+		formatter:     l.config.FormatterRegistry.FileFormmater,
+	}
+	if err := f.validate(time.Now().In(l.config.Location)); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// validate creates the log path if it does not exist, checks that a new file can be created in it,
+// and opens the log file of t. The file is opened again by the first Write.
+func (f *fileWriter) validate(t time.Time) error {
+	if err := os.MkdirAll(f.logPath, 0755); err != nil {
+		return logPathError(err)
+	}
+	tmp, err := os.CreateTemp(f.logPath, ".go-log-*.tmp")
+	if err != nil {
+		return logPathError(err)
+	}
+	_ = tmp.Close()
+	_ = os.Remove(tmp.Name())
+
+	name, segment := f.fileName(f.pattern.period(t))
+	file, err := os.OpenFile(segmentFileName(name, segment), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return logPathError(err)
+	}
+	return file.Close()
+}
+
+// logPathError wraps the error of the file system with ErrLogPathReadOnly, ErrLogPathPermission,
+// ErrLogPathNotFound or ErrLogPath.
+func logPathError(err error) error {
+	switch {
+	case errors.Is(err, syscall.EROFS):
+		return fmt.Errorf("%w: %w", ErrLogPathReadOnly, err)
+	case errors.Is(err, fs.ErrPermission):
+		return fmt.Errorf("%w: %w", ErrLogPathPermission, err)
+	case errors.Is(err, fs.ErrNotExist), errors.Is(err, syscall.ENOTDIR):
+		return fmt.Errorf("%w: %w", ErrLogPathNotFound, err)
+	}
+	return fmt.Errorf("%w: %w", ErrLogPath, err)
+}
+
+func (f *fileWriter) generatedFileName(period time.Time) string {
+	return filepath.Join(f.logPath, f.pattern.format(period))
+}
+
+// fileName returns the base file name and the segment to write in the period.
+func (f *fileWriter) fileName(period time.Time) (string, int) {
+	base := f.generatedFileName(period)
+	if f.maxSize > 0 || f.compress {
+		return base, lastSegment(base)
+	}
+	return base, 0
+}
+
+// isLogFile reports whether the base name is a file written by the writer, compressed or not,
+// e.g. name.2024-01-01.log, name.2024-01-01.2.log or name.2024-01-01.log.gz for DAILYMODE.
+func (f *fileWriter) isLogFile(name string) bool {
+	return f.pattern.matches(name)
+}
+
+// segmentFileName returns the file name of the segment, e.g. "name.2024-01-01.2.log" for segment 2.
+// Segment 0 is the base file name itself.
+func segmentFileName(base string, segment int) string {
+	if segment == 0 {
+		return base
+	}
+	ext := filepath.Ext(base)
+	return fmt.Sprintf("%s.%d%s", strings.TrimSuffix(base, ext), segment, ext)
+}
+
+// lastSegment returns the highest segment number of the base file name in the log path,
+// so that a restarted logger continues the last segment instead of the first one.
+// A compressed segment, or one being compressed by the janitor, is never continued, so the next number is returned for it.
+func lastSegment(base string) int {
+	entries, err := os.ReadDir(filepath.Dir(base))
+	if err != nil {
+		return 0
+	}
+	ext := filepath.Ext(base)
+	prefix := strings.TrimSuffix(filepath.Base(base), ext) + "."
+	lastPlain, lastCompressed := 0, -1
+	for _, entry := range entries {
+		name, compressed := strings.CutSuffix(entry.Name(), compressingSuffix)
+		if !compressed {
+			name, compressed = strings.CutSuffix(entry.Name(), compressedSuffix)
+		}
+		segment := 0
+		if name != filepath.Base(base) {
+			if !strings.HasPrefix(name, prefix) || !strings.HasSuffix(name, ext) || len(name) <= len(prefix)+len(ext) {
+				continue
+			}
+			if segment, err = strconv.Atoi(name[len(prefix) : len(name)-len(ext)]); err != nil {
+				continue
+			}
+		}
+		if compressed {
+			lastCompressed = max(lastCompressed, segment)
+		} else {
+			lastPlain = max(lastPlain, segment)
+		}
+	}
+	if lastCompressed >= lastPlain {
+		return lastCompressed + 1
+	}
+	return lastPlain
+}
+
+func (f *fileWriter) Write(t time.Time, level LogLevel, fields []Field, format string, args ...any) (n int, err error) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+
+	f.checkFile()
+	// the file name is generated only when the period changes
+	if period := f.pattern.period(t); !period.Equal(f.period) || f.file == nil {
+		if err := f.open(f.fileName(period)); err != nil {
+			return 0, err
+		}
+		f.period = period
+	}
+
+	msg := f.formatter(t, level, fields, format, args...)
+	if f.maxSize > 0 && f.size > 0 && f.size+int64(len(msg)) > f.maxSize {
+		if err := f.open(f.baseFileName, f.segment+1); err != nil {
+			return 0, err
+		}
+	}
+
+	var w io.Writer = f.file
+	if f.buf != nil {
+		w = f.buf
+	}
+	n, err = io.WriteString(w, msg)
+	f.size += int64(n)
+	if err == nil && level >= ERROR {
+		err = f.flushLocked(f.fsync == FsyncError)
+	}
+	return n, err
+}
+
+// open flushes and closes the current file and opens the segment of the base file name.
+// The size of the file is read once here and then tracked by Write.
+func (f *fileWriter) open(base string, segment int) error {
+	if f.file != nil {
+		if err := f.flushLocked(f.fsync != FsyncNever); err != nil {
+			f.handleError(err)
+		}
+		_ = f.file.Close()
+		f.file = nil
+	}
+	name := segmentFileName(base, segment)
+	file, err := os.OpenFile(name, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	var size int64
+	info, err := file.Stat()
+	if err == nil {
+		size = info.Size()
+	}
+	f.file = file
+	f.info = info
+	f.checked = time.Now()
+	if f.bufferSize > 0 {
+		if f.buf == nil {
+			f.buf = bufio.NewWriterSize(file, f.bufferSize)
+		} else {
+			f.buf.Reset(file)
+		}
+	}
+	f.startFlusher()
+	f.baseFileName = base
+	f.segment = segment
+	f.currentFileName = name
+	f.size = size
+	f.updateSymlink(name)
+	if f.compress || f.maxAge > 0 || f.maxFiles > 0 {
+		f.janitor.run(f.housekeeping)
+	}
+	return nil
+}
+
+// Close flushes and closes the file and waits for the janitor. The next Write opens the file again.
+func (f *fileWriter) Close() error {
+	f.stopFlushing()
+	f.janitor.wait()
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	return f.closeLocked()
+}
+
+// closeLocked flushes and closes the file. f.mtx must be held.
+func (f *fileWriter) closeLocked() error {
+	if f.file == nil {
+		return nil
+	}
+	err := errors.Join(f.flushLocked(f.fsync != FsyncNever), f.file.Close())
+	f.file = nil
+	f.info = nil
+	f.baseFileName = ""
+	f.currentFileName = ""
+	return err
+}
@@ -0,0 +1,123 @@
+package log
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// flusher is implemented by writers that buffer entries or write them asynchronously.
+// Flush is called by Logger.Flush after the entries queued before it are written,
+// and returns when the buffered or in-flight entries are written.
+type flusher interface {
+	Flush() error
+}
+
+// Flush waits until every entry logged before the call has been written by all outputs,
+// including the in-flight requests of the remote output.
+// It returns the errors of the outputs, or an error that wraps ctx.Err() and lists
+// the outputs with unwritten entries if ctx is done first.
+func (l *logger) Flush(ctx context.Context) error {
+	return l.dynamicWriter.flush(ctx)
+}
+
+// CloseContext closes the logger like Close, but returns when ctx is done even if
+// the outputs have not written all entries, e.g. when the remote endpoint hangs.
+// It returns the errors of closing the outputs, or an error that wraps ctx.Err() and lists
+// the outputs with unwritten entries if ctx is done first.
+func (l *logger) CloseContext(ctx context.Context) error {
+	done := make(chan error, 1)
+	go func() {
+		done <- l.dynamicWriter.close()
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return l.dynamicWriter.unwrittenError(ctx.Err())
+	}
+}
+
+func (d *dynamicWriter) flush(ctx context.Context) error {
+	return d.eachOutput(ctx, flushOutput)
+}
+
+// flushOutput flushes the writer of the output if it implements flusher.
+func flushOutput(o *output) error {
+	if f, ok := o.writer.(flusher); ok {
+		if err := f.Flush(); err != nil {
+			return fmt.Errorf("output %q: %w", o.name, err)
+		}
+	}
+	return nil
+}
+
+// eachOutput runs fn for every output after the entries queued before it are written,
+// and returns the joined errors of fn.
+func (d *dynamicWriter) eachOutput(ctx context.Context, fn func(o *output) error) error {
+	result := make(chan error, 1)
+	if d.syncMode {
+		if err := d.doSync(func() error { d.runOutputs(fn, result); return nil }); err != nil {
+			return err
+		}
+		return <-result
+	}
+	if err := d.sendOp(ctx, &logEntry{op: func() { d.runOutputs(fn, result) }}); err != nil {
+		if ctx.Err() != nil {
+			return d.unwrittenError(err)
+		}
+		return err
+	}
+	select {
+	case err := <-result:
+		return err
+	case <-ctx.Done():
+		return d.unwrittenError(ctx.Err())
+	}
+}
+
+// runOutputs queues fn to every output and sends the joined errors to result
+// when fn has run for all outputs. It runs in the dispatcher goroutine and does not wait for the outputs,
+// and the op is handed to each output with sendOp, so a stuck output does not block the dispatcher goroutine.
+// fn runs directly for the synchronous outputs.
+func (d *dynamicWriter) runOutputs(fn func(o *output) error, result chan<- error) {
+	d.mtx.RLock()
+	defer d.mtx.RUnlock()
+	errs := make([]error, len(d.writers))
+	remaining := make(chan struct{}, len(d.writers))
+	for i, o := range d.writers {
+		if o.sync {
+			o.mtx.Lock()
+			errs[i] = fn(o)
+			o.mtx.Unlock()
+			remaining <- struct{}{}
+			continue
+		}
+		o.sendOp(&logEntry{op: func() {
+			errs[i] = fn(o)
+			remaining <- struct{}{}
+		}})
+	}
+	go func() {
+		for range errs {
+			<-remaining
+		}
+		result <- errors.Join(errs...)
+	}()
+}
+
+// unwrittenError returns err joined with the number of entries left in each queue.
+func (d *dynamicWriter) unwrittenError(err error) error {
+	errs := []error{err}
+	for name, depth := range d.queueDepth() {
+		if depth == 0 {
+			continue
+		}
+		if name == "" {
+			errs = append(errs, fmt.Errorf("%d entries not dispatched", depth))
+			continue
+		}
+		errs = append(errs, fmt.Errorf("output %q: %d entries not written", name, depth))
+	}
+	return errors.Join(errs...)
+}
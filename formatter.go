@@ -8,30 +8,57 @@ import (
 
 // Formatter is a function that formats log entries.
 // The Formatter function is called by the logger to format log entries.
-type Formatter func(t time.Time, level LogLevel, format string, args ...any) string
+// fields holds the key-value pairs attached with With or the ...w methods.
+type Formatter func(t time.Time, level LogLevel, fields []Field, format string, args ...any) string
 
-func defaultFormatter(t time.Time, level LogLevel, format string, args ...any) string {
+func defaultFormatter(t time.Time, level LogLevel, fields []Field, format string, args ...any) string {
 	var logStr strings.Builder
 	logStr.WriteString(t.Format(time.TimeOnly + ".000"))
 	logStr.WriteString(" [")
 	logStr.WriteString(LoglevelNames[level])
 	logStr.WriteString("] ")
-	fmt.Fprintf(&logStr, format, args...)
+	if len(fields) > 0 && fields[0].Key == LoggerKey {
+		fmt.Fprint(&logStr, fields[0].Value)
+		logStr.WriteString(": ")
+		fields = fields[1:]
+	}
+	var stacktrace Stacktrace
+	if len(fields) > 0 {
+		if s, ok := fields[len(fields)-1].Value.(Stacktrace); ok {
+			stacktrace = s
+			fields = fields[:len(fields)-1]
+		}
+	}
+	if len(fields) == 0 && stacktrace == "" {
+		fmt.Fprintf(&logStr, format, args...)
+		return logStr.String()
+	}
+	// fields are written before the trailing newline of the message
+	// and the stack trace is written after it as indented lines
+	message := fmt.Sprintf(format, args...)
+	trimmed := strings.TrimSuffix(message, "\n")
+	logStr.WriteString(trimmed)
+	writeFields(&logStr, fields)
+	if stacktrace != "" {
+		logStr.WriteByte('\n')
+		writeStacktrace(&logStr, stacktrace)
+	} else if len(trimmed) != len(message) {
+		logStr.WriteByte('\n')
+	}
 	return logStr.String()
 	// return fmt.Sprintf("%s [%5s] %s", t.In(time.Local).Format(time.TimeOnly+".000"), LoglevelNames[level], fmt.Sprintf(format, args...))
 }
 
 // custom formatter is a function that formats log entries.
 // example:
-// func CustomFormatter(t time.Time, level LogLevel, format string, args ...any) string {
-// 	   var customLogEntry struct {
-// 		   Time string
-// 		   Level string
-// 		   Message string
-// 	   } {
-// 		   Time: t.UTC.String(),
-// 		   Level: log.LoglevelNames[level],
-// 		   Message: fmt.Sprintf(format, args...),
+// func CustomFormatter(t time.Time, level LogLevel, fields []log.Field, format string, args ...any) string {
+// 	   var customLogEntry = map[string]any{
+// 		   "Time": t.UTC().String(),
+// 		   "Level": log.LoglevelNames[level],
+// 		   "Message": fmt.Sprintf(format, args...),
+// 	   }
+// 	   for _, field := range fields {
+// 		   customLogEntry[field.Key] = field.Value
 // 	   }
 //     dat, _ := json.Marshal(customLogEntry)
 // 	   return fmt.Sprintf("%s\n", string(dat))
@@ -0,0 +1,80 @@
+package log
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+)
+
+// AfterClosePolicy decides what happens to entries logged after Close is called.
+type AfterClosePolicy int
+
+const (
+	// AfterCloseDiscard discards the entries. It is the default.
+	AfterCloseDiscard AfterClosePolicy = iota
+	// AfterCloseStderr writes the entries to os.Stderr with the standard formatter.
+	AfterCloseStderr
+	// AfterCloseSync waits until Close finishes and then writes the entries directly to the outputs.
+	// Each write reopens and closes the output, so it is meant for a few late entries only.
+	AfterCloseSync
+)
+
+// The states of the dynamic writer.
+// Entries are queued only while the state is stateRunning.
+const (
+	stateRunning int32 = iota
+	stateClosing       // Close is writing the queued entries
+	stateClosed        // the outputs are closed
+)
+
+// sendEntry queues the entry while the dynamic writer is running,
+// and handles it with the after close policy otherwise.
+// sendMtx makes sure that no entry is sent after the queue is closed by close.
+func (d *dynamicWriter) sendEntry(entry *logEntry, send func(*logEntry)) {
+	d.sendMtx.RLock()
+	if d.state.Load() != stateRunning {
+		d.sendMtx.RUnlock()
+		d.afterClose(entry)
+		return
+	}
+	send(entry)
+	d.sendMtx.RUnlock()
+}
+
+// sendOp queues an op while the dynamic writer is running. It returns ErrClosed otherwise.
+func (d *dynamicWriter) sendOp(ctx context.Context, entry *logEntry) error {
+	d.sendMtx.RLock()
+	defer d.sendMtx.RUnlock()
+	if d.state.Load() != stateRunning {
+		return ErrClosed
+	}
+	select {
+	case d.ch <- entry:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (d *dynamicWriter) afterClose(entry *logEntry) {
+	switch d.afterClosePolicy {
+	case AfterCloseStderr:
+		fmt.Fprint(os.Stderr, d.fallbackFormatter(entry.t, entry.level, entry.fields, entry.format, entry.args...))
+	case AfterCloseSync:
+		<-d.closed
+		d.syncMtx.Lock()
+		defer d.syncMtx.Unlock()
+		for _, output := range d.writers {
+			if !output.accepts(entry) {
+				continue
+			}
+			if _, err := output.writer.Write(entry.t, entry.level, entry.fields, entry.format, entry.args...); err != nil {
+				d.handleError(output, err)
+			}
+			if c, ok := output.writer.(io.Closer); ok {
+				_ = c.Close()
+			}
+		}
+	}
+}
@@ -1,44 +1,110 @@
 package log
 
 import (
+	"context"
+	"fmt"
 	"os"
+	"strings"
 	"sync"
 	"time"
 )
 
 // Logger is the interface that wraps the basic logging methods.
 type Logger interface {
+	Trace(format string, args ...any)
 	Debug(format string, args ...any)
 	Info(format string, args ...any)
 	Warn(format string, args ...any)
 	Error(format string, args ...any)
+	// Panic logs a message with the PANIC level, closes the logger and panics with the message.
+	Panic(format string, args ...any)
+	// Fatal logs a message with the FATAL level, closes the logger and calls the exit function with 1.
+	Fatal(format string, args ...any)
+
+	// TraceE logs a message with the TRACE level and returns the errors of the synchronous outputs.
+	TraceE(format string, args ...any) error
+	// DebugE logs a message with the DEBUG level and returns the errors of the synchronous outputs.
+	DebugE(format string, args ...any) error
+	// InfoE logs a message with the INFO level and returns the errors of the synchronous outputs.
+	InfoE(format string, args ...any) error
+	// WarnE logs a message with the WARN level and returns the errors of the synchronous outputs.
+	WarnE(format string, args ...any) error
+	// ErrorE logs a message with the ERROR level and returns the errors of the synchronous outputs.
+	ErrorE(format string, args ...any) error
+
+	// Tracew logs a message with the TRACE level and key-value pairs.
+	Tracew(msg string, keysAndValues ...any)
+	// Debugw logs a message with the DEBUG level and key-value pairs.
+	Debugw(msg string, keysAndValues ...any)
+	// Infow logs a message with the INFO level and key-value pairs.
+	Infow(msg string, keysAndValues ...any)
+	// Warnw logs a message with the WARN level and key-value pairs.
+	Warnw(msg string, keysAndValues ...any)
+	// Errorw logs a message with the ERROR level and key-value pairs.
+	Errorw(msg string, keysAndValues ...any)
+	// Panicw logs a message with the PANIC level and key-value pairs, closes the logger and panics with the message.
+	Panicw(msg string, keysAndValues ...any)
+	// Fatalw logs a message with the FATAL level and key-value pairs, closes the logger and calls the exit function with 1.
+	Fatalw(msg string, keysAndValues ...any)
+
+	// TraceCtx logs a message with the TRACE level and the fields extracted from ctx.
+	TraceCtx(ctx context.Context, format string, args ...any)
+	// DebugCtx logs a message with the DEBUG level and the fields extracted from ctx.
+	DebugCtx(ctx context.Context, format string, args ...any)
+	// InfoCtx logs a message with the INFO level and the fields extracted from ctx.
+	InfoCtx(ctx context.Context, format string, args ...any)
+	// WarnCtx logs a message with the WARN level and the fields extracted from ctx.
+	WarnCtx(ctx context.Context, format string, args ...any)
+	// ErrorCtx logs a message with the ERROR level and the fields extracted from ctx.
+	ErrorCtx(ctx context.Context, format string, args ...any)
+
+	// With returns a child logger that attaches the key-value pairs to every entry.
+	With(keysAndValues ...any) Logger
+	// Named returns a child logger with the dotted name, e.g. "app.db".
+	Named(name string) Logger
+	// V returns a Verbose that logs only when the verbosity level is enabled.
+	V(level int) Verbose
 }
 
+// logger is shared with its child loggers created by With and Named.
+// The child loggers share config, dynamicWriter, mtx, entryPool and verbosity with the parent
+// and only differ in the name and the fields attached to every entry.
 type logger struct {
 	name          string
 	config        *Config
 	dynamicWriter *dynamicWriter
-	mtx           sync.RWMutex
-	entryPool     sync.Pool
+	mtx           *sync.RWMutex
+	entryPool     *sync.Pool
+	verbosity     *verbosity
+	root          *logger // logger created by NewLogger
+	fields        []Field
 }
 
 var globalLogger *logger
 
 type LogLevel int
 
+// The values of DEBUG to ERROR are stable. TRACE is below DEBUG and below NONE,
+// since NONE is the zero value that means no level.
 const (
+	TRACE LogLevel = -1
 	NONE  LogLevel = 0
-	DEBUG LogLevel = 1 + iota
-	INFO           // 2
-	WARN           // 3
-	ERROR          // 4
+	DEBUG LogLevel = 1
+	INFO  LogLevel = 2
+	WARN  LogLevel = 3
+	ERROR LogLevel = 4
+	PANIC LogLevel = 5
+	FATAL LogLevel = 6
 )
 
 var LoglevelNames = map[LogLevel]string{
+	TRACE: "TRACE",
 	DEBUG: "DEBUG",
 	INFO:  "INFO",
 	WARN:  "WARN",
 	ERROR: "ERROR",
+	PANIC: "PANIC",
+	FATAL: "FATAL",
 }
 
 type OutputMode uint8
@@ -50,6 +116,8 @@ const (
 	OutputModeFile // 2
 	// OutputModeRemote is the output mode for the remote.
 	OutputModeRemote // 4
+	// OutputModeCustom is the output mode reported to the ErrorHandler for the writers registered with WithWriter.
+	OutputModeCustom // 8
 )
 
 // NewLoggerFormConfig creates a new logger from the configuration.
@@ -69,16 +137,21 @@ func NewLogger(name string, opts ...LogOption) (*logger, error) {
 				Writer: os.Stdout,
 			},
 			EntrySize:         4096,
+			ExitFunc:          os.Exit,
 			StandardFormatter: defaultFormatter,
 			FormatterRegistry: &FormatterRegistry{},
 		},
-		entryPool: sync.Pool{
+		mtx:       &sync.RWMutex{},
+		verbosity: &verbosity{},
+		entryPool: &sync.Pool{
 			New: func() any {
 				return &logEntry{}
 			},
 		},
 	}
 
+	logger.root = logger
+
 	for _, opt := range opts {
 		opt(logger)
 	}
@@ -86,6 +159,11 @@ func NewLogger(name string, opts ...LogOption) (*logger, error) {
 	return logger.init()
 }
 
+// Trace logs a message with the TRACE level.
+func (l *logger) Trace(format string, args ...any) {
+	l.logf(TRACE, format, args...)
+}
+
 // Debug logs a message with the DEBUG level.
 func (l *logger) Debug(format string, args ...any) {
 	l.logf(DEBUG, format, args...)
@@ -106,18 +184,201 @@ func (l *logger) Error(format string, args ...any) {
 	l.logf(ERROR, format, args...)
 }
 
-// logf logs a message with the level and format.
-func (l *logger) logf(level LogLevel, format string, args ...any) {
-	if level < l.config.Level {
+// Panic logs a message with the PANIC level and panics with the message.
+// The remaining entries are written and the logger is closed before panicking,
+// so entries logged after the panic is recovered are handled by the after close policy.
+func (l *logger) Panic(format string, args ...any) {
+	l.logf(PANIC, format, args...)
+	l.panic(fmt.Sprintf(format, args...))
+}
+
+// Fatal logs a message with the FATAL level and calls the exit function with 1.
+// The remaining entries are written and the logger is closed before exiting.
+// The exit function is os.Exit by default and can be changed with WithExitFunc.
+func (l *logger) Fatal(format string, args ...any) {
+	l.logf(FATAL, format, args...)
+	l.exit(1)
+}
+
+// TraceE logs a message with the TRACE level and returns the errors of the synchronous outputs.
+// The errors are also reported to the ErrorHandler. Entries written by the asynchronous outputs
+// are not waited for, so TraceE returns nil for them. See WithSyncMode.
+func (l *logger) TraceE(format string, args ...any) error {
+	return l.logf(TRACE, format, args...)
+}
+
+// DebugE logs a message with the DEBUG level and returns the errors of the synchronous outputs.
+// See TraceE for details.
+func (l *logger) DebugE(format string, args ...any) error {
+	return l.logf(DEBUG, format, args...)
+}
+
+// InfoE logs a message with the INFO level and returns the errors of the synchronous outputs.
+// See TraceE for details.
+func (l *logger) InfoE(format string, args ...any) error {
+	return l.logf(INFO, format, args...)
+}
+
+// WarnE logs a message with the WARN level and returns the errors of the synchronous outputs.
+// See TraceE for details.
+func (l *logger) WarnE(format string, args ...any) error {
+	return l.logf(WARN, format, args...)
+}
+
+// ErrorE logs a message with the ERROR level and returns the errors of the synchronous outputs.
+// See TraceE for details.
+func (l *logger) ErrorE(format string, args ...any) error {
+	return l.logf(ERROR, format, args...)
+}
+
+// Tracew logs a message with the TRACE level and key-value pairs.
+func (l *logger) Tracew(msg string, keysAndValues ...any) {
+	l.logw(TRACE, msg, keysAndValues...)
+}
+
+// Debugw logs a message with the DEBUG level and key-value pairs.
+func (l *logger) Debugw(msg string, keysAndValues ...any) {
+	l.logw(DEBUG, msg, keysAndValues...)
+}
+
+// Infow logs a message with the INFO level and key-value pairs.
+func (l *logger) Infow(msg string, keysAndValues ...any) {
+	l.logw(INFO, msg, keysAndValues...)
+}
+
+// Warnw logs a message with the WARN level and key-value pairs.
+func (l *logger) Warnw(msg string, keysAndValues ...any) {
+	l.logw(WARN, msg, keysAndValues...)
+}
+
+// Errorw logs a message with the ERROR level and key-value pairs.
+func (l *logger) Errorw(msg string, keysAndValues ...any) {
+	l.logw(ERROR, msg, keysAndValues...)
+}
+
+// Panicw logs a message with the PANIC level and key-value pairs and panics with the message.
+// See Panic for details.
+func (l *logger) Panicw(msg string, keysAndValues ...any) {
+	l.logw(PANIC, msg, keysAndValues...)
+	l.panic(msg)
+}
+
+// Fatalw logs a message with the FATAL level and key-value pairs and calls the exit function with 1.
+// See Fatal for details.
+func (l *logger) Fatalw(msg string, keysAndValues ...any) {
+	l.logw(FATAL, msg, keysAndValues...)
+	l.exit(1)
+}
+
+// panic closes the logger and panics with the message.
+func (l *logger) panic(msg string) {
+	l.Close()
+	panic(strings.TrimSuffix(msg, "\n"))
+}
+
+// exit closes the logger and calls the exit function with the code.
+func (l *logger) exit(code int) {
+	l.Close()
+	l.config.ExitFunc(code)
+}
+
+// TraceCtx logs a message with the TRACE level and the fields extracted from ctx.
+func (l *logger) TraceCtx(ctx context.Context, format string, args ...any) {
+	l.logCtx(ctx, TRACE, format, args...)
+}
+
+// DebugCtx logs a message with the DEBUG level and the fields extracted from ctx.
+func (l *logger) DebugCtx(ctx context.Context, format string, args ...any) {
+	l.logCtx(ctx, DEBUG, format, args...)
+}
+
+// InfoCtx logs a message with the INFO level and the fields extracted from ctx.
+func (l *logger) InfoCtx(ctx context.Context, format string, args ...any) {
+	l.logCtx(ctx, INFO, format, args...)
+}
+
+// WarnCtx logs a message with the WARN level and the fields extracted from ctx.
+func (l *logger) WarnCtx(ctx context.Context, format string, args ...any) {
+	l.logCtx(ctx, WARN, format, args...)
+}
+
+// ErrorCtx logs a message with the ERROR level and the fields extracted from ctx.
+func (l *logger) ErrorCtx(ctx context.Context, format string, args ...any) {
+	l.logCtx(ctx, ERROR, format, args...)
+}
+
+// With returns a child logger that attaches the key-value pairs to every entry.
+// The child logger shares the outputs and the configuration with the parent.
+// keysAndValues are alternating keys and values, e.g. With("user", id, "method", "GET").
+func (l *logger) With(keysAndValues ...any) Logger {
+	return l.with(toFields(keysAndValues))
+}
+
+func (l *logger) with(fields []Field) *logger {
+	child := l.clone()
+	child.fields = mergeFields(l.fields, fields)
+	return child
+}
+
+// clone returns a child logger that shares everything with the logger.
+func (l *logger) clone() *logger {
+	child := *l
+	return &child
+}
+
+// logf logs a message with the level and format and returns the errors of the synchronous outputs.
+func (l *logger) logf(level LogLevel, format string, args ...any) error {
+	return l.log(level, l.fields, format, args...)
+}
+
+// logw logs a message with the level and key-value pairs.
+// A newline is appended to msg unless it already ends with one.
+func (l *logger) logw(level LogLevel, msg string, keysAndValues ...any) {
+	if !l.enabled(level) {
+		return
+	}
+	if !strings.HasSuffix(msg, "\n") {
+		msg += "\n"
+	}
+	l.log(level, mergeFields(l.fields, toFields(keysAndValues)), "%s", msg)
+}
+
+// logCtx logs a message with the level, format and the fields extracted from ctx.
+func (l *logger) logCtx(ctx context.Context, level LogLevel, format string, args ...any) {
+	if !l.enabled(level) {
 		return
 	}
+	l.log(level, mergeFields(l.fields, l.contextFields(ctx)), format, args...)
+}
+
+func (l *logger) log(level LogLevel, fields []Field, format string, args ...any) error {
+	threshold := l.level()
+	if level < l.dynamicWriter.minLevelFor(threshold) {
+		return nil
+	}
+	if l.config.Caller {
+		if caller, ok := l.callerField(); ok {
+			fields = mergeFields(fields, []Field{caller})
+		}
+	}
+	if l.config.StacktraceLevel != NONE && level >= l.config.StacktraceLevel {
+		fields = mergeFields(fields, []Field{l.stacktraceField(fields, args)})
+	}
+	return l.logAt(time.Now(), level, threshold, fields, format, args...)
+}
+
+// logAt sends an entry with the given time to the dynamic writer and returns the errors of the synchronous outputs.
+// The level must already be checked by the caller and threshold is the level of the logger.
+func (l *logger) logAt(t time.Time, level, threshold LogLevel, fields []Field, format string, args ...any) error {
 	entry := l.entryPool.Get().(*logEntry)
-	entry.t = time.Now().In(l.config.Location)
+	entry.t = t.In(l.config.Location)
 	entry.level = level
+	entry.threshold = threshold
+	entry.fields = fields
 	entry.format = format
 	entry.args = args
 
-	l.dynamicWriter.ch <- entry
+	return l.dynamicWriter.send(entry)
 }
 
 // SetLogLevel sets the log level of the logger.
@@ -127,12 +388,20 @@ func (l *logger) SetLogLevel(level LogLevel) {
 	l.config.Level = level
 }
 
+// Trace logs a message with the TRACE level. It is a wrapper for the global logger.
+func Trace(format string, args ...any) {
+	if globalLogger == nil {
+		return
+	}
+	globalLogger.logf(TRACE, format, args...)
+}
+
 // Debug logs a message with the DEBUG level. It is a wrapper for the global logger.
 func Debug(format string, args ...any) {
 	if globalLogger == nil {
 		return
 	}
-	globalLogger.Debug(format, args...)
+	globalLogger.logf(DEBUG, format, args...)
 }
 
 // Info logs a message with the INFO level. It is a wrapper for the global logger.
@@ -140,7 +409,7 @@ func Info(format string, args ...any) {
 	if globalLogger == nil {
 		return
 	}
-	globalLogger.Info(format, args...)
+	globalLogger.logf(INFO, format, args...)
 }
 
 // Warn logs a message with the WARN level. It is a wrapper for the global logger.
@@ -148,7 +417,7 @@ func Warn(format string, args ...any) {
 	if globalLogger == nil {
 		return
 	}
-	globalLogger.Warn(format, args...)
+	globalLogger.logf(WARN, format, args...)
 }
 
 // Error logs a message with the ERROR level. It is a wrapper for the global logger.
@@ -156,7 +425,183 @@ func Error(format string, args ...any) {
 	if globalLogger == nil {
 		return
 	}
-	globalLogger.Error(format, args...)
+	globalLogger.logf(ERROR, format, args...)
+}
+
+// Panic logs a message with the PANIC level and panics with the message. It is a wrapper for the global logger.
+// It panics even if the global logger is not set.
+func Panic(format string, args ...any) {
+	if globalLogger == nil {
+		panic(strings.TrimSuffix(fmt.Sprintf(format, args...), "\n"))
+	}
+	globalLogger.logf(PANIC, format, args...)
+	globalLogger.panic(fmt.Sprintf(format, args...))
+}
+
+// Fatal logs a message with the FATAL level and calls the exit function with 1. It is a wrapper for the global logger.
+// It writes the message to stderr and calls os.Exit(1) if the global logger is not set.
+func Fatal(format string, args ...any) {
+	if globalLogger == nil {
+		exitWithoutLogger(nil, format, args...)
+	}
+	globalLogger.logf(FATAL, format, args...)
+	globalLogger.exit(1)
+}
+
+// TraceE logs a message with the TRACE level and returns the errors of the synchronous outputs.
+// It is a wrapper for the global logger.
+func TraceE(format string, args ...any) error {
+	if globalLogger == nil {
+		return nil
+	}
+	return globalLogger.logf(TRACE, format, args...)
+}
+
+// DebugE logs a message with the DEBUG level and returns the errors of the synchronous outputs.
+// It is a wrapper for the global logger.
+func DebugE(format string, args ...any) error {
+	if globalLogger == nil {
+		return nil
+	}
+	return globalLogger.logf(DEBUG, format, args...)
+}
+
+// InfoE logs a message with the INFO level and returns the errors of the synchronous outputs.
+// It is a wrapper for the global logger.
+func InfoE(format string, args ...any) error {
+	if globalLogger == nil {
+		return nil
+	}
+	return globalLogger.logf(INFO, format, args...)
+}
+
+// WarnE logs a message with the WARN level and returns the errors of the synchronous outputs.
+// It is a wrapper for the global logger.
+func WarnE(format string, args ...any) error {
+	if globalLogger == nil {
+		return nil
+	}
+	return globalLogger.logf(WARN, format, args...)
+}
+
+// ErrorE logs a message with the ERROR level and returns the errors of the synchronous outputs.
+// It is a wrapper for the global logger.
+func ErrorE(format string, args ...any) error {
+	if globalLogger == nil {
+		return nil
+	}
+	return globalLogger.logf(ERROR, format, args...)
+}
+
+// Tracew logs a message with the TRACE level and key-value pairs. It is a wrapper for the global logger.
+func Tracew(msg string, keysAndValues ...any) {
+	if globalLogger == nil {
+		return
+	}
+	globalLogger.logw(TRACE, msg, keysAndValues...)
+}
+
+// Debugw logs a message with the DEBUG level and key-value pairs. It is a wrapper for the global logger.
+func Debugw(msg string, keysAndValues ...any) {
+	if globalLogger == nil {
+		return
+	}
+	globalLogger.logw(DEBUG, msg, keysAndValues...)
+}
+
+// Infow logs a message with the INFO level and key-value pairs. It is a wrapper for the global logger.
+func Infow(msg string, keysAndValues ...any) {
+	if globalLogger == nil {
+		return
+	}
+	globalLogger.logw(INFO, msg, keysAndValues...)
+}
+
+// Warnw logs a message with the WARN level and key-value pairs. It is a wrapper for the global logger.
+func Warnw(msg string, keysAndValues ...any) {
+	if globalLogger == nil {
+		return
+	}
+	globalLogger.logw(WARN, msg, keysAndValues...)
+}
+
+// Errorw logs a message with the ERROR level and key-value pairs. It is a wrapper for the global logger.
+func Errorw(msg string, keysAndValues ...any) {
+	if globalLogger == nil {
+		return
+	}
+	globalLogger.logw(ERROR, msg, keysAndValues...)
+}
+
+// Panicw logs a message with the PANIC level and key-value pairs and panics with the message. It is a wrapper for the global logger.
+// It panics even if the global logger is not set.
+func Panicw(msg string, keysAndValues ...any) {
+	if globalLogger == nil {
+		panic(strings.TrimSuffix(msg, "\n"))
+	}
+	globalLogger.logw(PANIC, msg, keysAndValues...)
+	globalLogger.panic(msg)
+}
+
+// Fatalw logs a message with the FATAL level and key-value pairs and calls the exit function with 1. It is a wrapper for the global logger.
+// It writes the message to stderr and calls os.Exit(1) if the global logger is not set.
+func Fatalw(msg string, keysAndValues ...any) {
+	if globalLogger == nil {
+		exitWithoutLogger(toFields(keysAndValues), "%s", msg)
+	}
+	globalLogger.logw(FATAL, msg, keysAndValues...)
+	globalLogger.exit(1)
+}
+
+// exitWithoutLogger writes the FATAL entry to stderr with the default formatter and calls os.Exit(1).
+// It is used by Fatal and Fatalw when the global logger is not set, so the message is not lost.
+func exitWithoutLogger(fields []Field, format string, args ...any) {
+	entry := defaultFormatter(time.Now(), FATAL, fields, format, args...)
+	if !strings.HasSuffix(entry, "\n") {
+		entry += "\n"
+	}
+	fmt.Fprint(os.Stderr, entry)
+	os.Exit(1)
+}
+
+// TraceCtx logs a message with the TRACE level and the fields extracted from ctx. It is a wrapper for the global logger.
+func TraceCtx(ctx context.Context, format string, args ...any) {
+	if globalLogger == nil {
+		return
+	}
+	globalLogger.logCtx(ctx, TRACE, format, args...)
+}
+
+// DebugCtx logs a message with the DEBUG level and the fields extracted from ctx. It is a wrapper for the global logger.
+func DebugCtx(ctx context.Context, format string, args ...any) {
+	if globalLogger == nil {
+		return
+	}
+	globalLogger.logCtx(ctx, DEBUG, format, args...)
+}
+
+// InfoCtx logs a message with the INFO level and the fields extracted from ctx. It is a wrapper for the global logger.
+func InfoCtx(ctx context.Context, format string, args ...any) {
+	if globalLogger == nil {
+		return
+	}
+	globalLogger.logCtx(ctx, INFO, format, args...)
+}
+
+// WarnCtx logs a message with the WARN level and the fields extracted from ctx. It is a wrapper for the global logger.
+func WarnCtx(ctx context.Context, format string, args ...any) {
+	if globalLogger == nil {
+		return
+	}
+	globalLogger.logCtx(ctx, WARN, format, args...)
+}
+
+// ErrorCtx logs a message with the ERROR level and the fields extracted from ctx. It is a wrapper for the global logger.
+func ErrorCtx(ctx context.Context, format string, args ...any) {
+	if globalLogger == nil {
+		return
+	}
+	globalLogger.logCtx(ctx, ERROR, format, args...)
 }
 
 // SetLogLevel sets the log level of the global logger.
@@ -170,49 +615,96 @@ func SetLogLevel(level LogLevel) {
 func (l *logger) init() (*logger, error) {
 	// 각 모드별 formatter 설정이 없을 경우 기본 포매터 사용
 	if l.config.OutputMode&OutputModeConsole != 0 {
-		if l.config.FormatterRegistry.ConsoleFormatter == nil {
-			l.config.FormatterRegistry.ConsoleFormatter = l.config.StandardFormatter
-		}
+		l.initConsoleConfig()
 	}
 
 	if l.config.OutputMode&OutputModeFile != 0 {
-		if l.config.FormatterRegistry.FileFormmater == nil {
-			l.config.FormatterRegistry.FileFormmater = l.config.StandardFormatter
-		}
-		if l.config.FileConfig == nil {
-			l.config.FileConfig = &FileConfig{
-				FileName:       l.name,
-				LogPath:        "log", // $HOME/log
-				FileCreateMode: DAILYMODE,
-			}
-		} else {
-			if l.config.FileConfig.FileName == "" {
-				l.config.FileConfig.FileName = l.name
-			}
-			if l.config.FileConfig.LogPath == "" {
-				l.config.FileConfig.LogPath = "log"
-			}
-		}
+		l.initFileConfig()
 	}
 
 	if l.config.OutputMode&OutputModeRemote != 0 {
-		if l.config.FormatterRegistry.RemoteFormatter == nil {
-			l.config.FormatterRegistry.RemoteFormatter = l.config.StandardFormatter
+		if err := l.initRemoteConfig(); err != nil {
+			return nil, err
 		}
-		if l.config.RemoteConfig == nil {
-			return nil, ErrRemoteConfig
-		} else if l.config.RemoteConfig.EndPoint == "" { // remote addr is required
-			return nil, ErrRemoteEndpoint
+	}
+
+	for _, w := range l.config.Writers {
+		switch w.Name {
+		case "", consoleOutputName, fileOutputName, remoteOutputName:
+			return nil, fmt.Errorf("%w: %q", ErrWriterName, w.Name)
+		}
+		if w.Writer == nil {
+			return nil, fmt.Errorf("%w: %q", ErrNilWriter, w.Name)
 		}
 	}
 
-	l.dynamicWriter = newDynamicWriter(l)
+	l.verbosity.level.Store(int32(l.config.Verbosity))
+	if err := l.verbosity.setVModule(l.config.VModule); err != nil {
+		return nil, err
+	}
+
+	dynamicWriter, err := newDynamicWriter(l)
+	if err != nil {
+		return nil, err
+	}
+	l.dynamicWriter = dynamicWriter
 	l.dynamicWriter.run()
+	if l.config.ReopenOnSignal {
+		l.dynamicWriter.notifyReopen(l.config.ReopenSignals)
+	}
+	if l.config.Global {
+		globalLogger = l
+	}
 	return l, nil
 }
 
+func (l *logger) initConsoleConfig() {
+	if l.config.FormatterRegistry.ConsoleFormatter == nil {
+		l.config.FormatterRegistry.ConsoleFormatter = l.config.StandardFormatter
+	}
+	if l.config.ConsoleConfig == nil {
+		l.config.ConsoleConfig = &ConsoleConfig{}
+	}
+	if l.config.ConsoleConfig.Writer == nil {
+		l.config.ConsoleConfig.Writer = os.Stdout
+	}
+}
+
+func (l *logger) initFileConfig() {
+	if l.config.FormatterRegistry.FileFormmater == nil {
+		l.config.FormatterRegistry.FileFormmater = l.config.StandardFormatter
+	}
+	if l.config.FileConfig == nil {
+		l.config.FileConfig = &FileConfig{
+			FileName:       l.name,
+			LogPath:        "log", // $HOME/log
+			FileCreateMode: DAILYMODE,
+		}
+	} else {
+		if l.config.FileConfig.FileName == "" {
+			l.config.FileConfig.FileName = l.name
+		}
+		if l.config.FileConfig.LogPath == "" {
+			l.config.FileConfig.LogPath = "log"
+		}
+	}
+}
+
+func (l *logger) initRemoteConfig() error {
+	if l.config.FormatterRegistry.RemoteFormatter == nil {
+		l.config.FormatterRegistry.RemoteFormatter = l.config.StandardFormatter
+	}
+	if l.config.RemoteConfig == nil {
+		return ErrRemoteConfig
+	} else if l.config.RemoteConfig.EndPoint == "" { // remote addr is required
+		return ErrRemoteEndpoint
+	}
+	return nil
+}
+
 // Close closes the logger.
 // It ensures that all remaining log entries in the channel are processed before shutting down.
+// Use CloseContext to bound the time or to get the errors of closing the outputs.
 func (l *logger) Close() {
-	l.dynamicWriter.close()
+	_ = l.dynamicWriter.close()
 }
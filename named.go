@@ -0,0 +1,87 @@
+package log
+
+import "strings"
+
+// LoggerKey is the key of the field that holds the dotted name of a logger created by Named.
+// The field is always the first field of the entry.
+const LoggerKey = "logger"
+
+// Named returns a child logger whose name is the name of the parent joined with a dot, e.g. "app.db".
+// The child logger shares the outputs and the configuration with the parent,
+// and its entries carry the name in the LoggerKey field.
+// The level of the child logger can be changed with SetLogLevelFor.
+func (l *logger) Named(name string) Logger {
+	return l.named(name)
+}
+
+func (l *logger) named(name string) *logger {
+	if name == "" {
+		return l
+	}
+	fullName := l.name + "." + name
+	fields := make([]Field, 0, len(l.fields)+1)
+	fields = append(fields, Field{Key: LoggerKey, Value: fullName})
+	fields = append(fields, l.loggerFields()...)
+	child := l.clone()
+	child.name = fullName
+	child.fields = fields
+	return child
+}
+
+// loggerFields returns the fields of the logger without the LoggerKey field.
+func (l *logger) loggerFields() []Field {
+	if len(l.fields) > 0 && l.fields[0].Key == LoggerKey {
+		return l.fields[1:]
+	}
+	return l.fields
+}
+
+// SetLogLevelFor sets the log level of the named logger and its descendants.
+// The name is the dotted name of the logger, e.g. "app.db".
+// The nearest ancestor with a level is used when a logger has no level of its own,
+// and the level of the logger configuration is used when no ancestor has one.
+// Setting NONE removes the level of the name.
+func (l *logger) SetLogLevelFor(name string, level LogLevel) {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+	if level == NONE {
+		delete(l.config.NamedLevels, name)
+		return
+	}
+	if l.config.NamedLevels == nil {
+		l.config.NamedLevels = make(map[string]LogLevel)
+	}
+	l.config.NamedLevels[name] = level
+}
+
+// enabled reports whether the level is written by any output of the logger.
+func (l *logger) enabled(level LogLevel) bool {
+	return level >= l.dynamicWriter.minLevelFor(l.level())
+}
+
+// level returns the effective level of the logger.
+func (l *logger) level() LogLevel {
+	l.mtx.RLock()
+	defer l.mtx.RUnlock()
+	if len(l.config.NamedLevels) == 0 {
+		return l.config.Level
+	}
+	for name := l.name; ; {
+		if level, ok := l.config.NamedLevels[name]; ok {
+			return level
+		}
+		i := strings.LastIndexByte(name, '.')
+		if i < 0 {
+			return l.config.Level
+		}
+		name = name[:i]
+	}
+}
+
+// SetLogLevelFor sets the log level of the named logger of the global logger.
+func SetLogLevelFor(name string, level LogLevel) {
+	if globalLogger == nil {
+		return
+	}
+	globalLogger.SetLogLevelFor(name, level)
+}
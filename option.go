@@ -3,16 +3,20 @@ package log
 import (
 	"io"
 	"net/http"
+	"os"
 	"time"
 )
 
 // LogOption is a function that sets the logger configuration.
 type LogOption func(*logger)
 
-// globalLogger is used in the option. It is set when the WithGlobal option is executed.
+// WithGlobal sets the logger as the global logger used by the package-level functions.
+// The global logger is set only when NewLogger succeeds.
 func WithGlobal() LogOption {
 	return func(l *logger) {
-		globalLogger = l
+		l.mtx.Lock()
+		defer l.mtx.Unlock()
+		l.config.Global = true
 	}
 }
 
@@ -34,6 +38,65 @@ func WithLevel(level LogLevel) LogOption {
 	}
 }
 
+// WithLevelFor sets the log level of the named logger and its descendants, e.g. "app.db".
+// See SetLogLevelFor for details.
+func WithLevelFor(name string, level LogLevel) LogOption {
+	return func(l *logger) {
+		l.SetLogLevelFor(name, level)
+	}
+}
+
+// WithCaller records the file, line and function of the code that logged an entry
+// in the CallerKey field. skip is the number of additional stack frames to skip,
+// which is used when the logger is wrapped by a helper function. The default is no caller.
+func WithCaller(skip int) LogOption {
+	return func(l *logger) {
+		l.mtx.Lock()
+		defer l.mtx.Unlock()
+		l.config.Caller = true
+		l.config.CallerSkip = skip
+	}
+}
+
+// WithStacktrace attaches the stack of the goroutine to entries at or above the level
+// in the StacktraceKey field. Wrapped errors passed as args or fields add their chains to the stack trace.
+// The default is NONE, which disables stack traces. WithStacktrace(ERROR) is the common setting.
+func WithStacktrace(level LogLevel) LogOption {
+	return func(l *logger) {
+		l.mtx.Lock()
+		defer l.mtx.Unlock()
+		l.config.StacktraceLevel = level
+	}
+}
+
+// WithExitFunc sets the function called by Fatal after the logger is closed. The default is os.Exit.
+func WithExitFunc(exit func(code int)) LogOption {
+	return func(l *logger) {
+		l.mtx.Lock()
+		defer l.mtx.Unlock()
+		l.config.ExitFunc = exit
+	}
+}
+
+// WithVerbosity sets the verbosity threshold used by V. The default is 0.
+func WithVerbosity(level int) LogOption {
+	return func(l *logger) {
+		l.mtx.Lock()
+		defer l.mtx.Unlock()
+		l.config.Verbosity = level
+	}
+}
+
+// WithVModule sets the per-file and per-logger verbosity in the form "pattern=N,...".
+// See SetVModule for the pattern syntax. NewLogger returns ErrInvalidVModule if the spec is invalid.
+func WithVModule(spec string) LogOption {
+	return func(l *logger) {
+		l.mtx.Lock()
+		defer l.mtx.Unlock()
+		l.config.VModule = spec
+	}
+}
+
 // WithEntrySize sets the size of the log entry. The default is 4096.
 func WithEntrySize(size int) LogOption {
 	return func(l *logger) {
@@ -43,6 +106,80 @@ func WithEntrySize(size int) LogOption {
 	}
 }
 
+// WithBackpressure sets what happens when the entry queue or the queue of an output is full. The default is BackpressureBlock.
+// With BackpressureBlock, an output whose queue is full blocks the logging calls until it catches up,
+// but the other outputs still receive every entry logged. The other policies drop the entries of that output only.
+// timeout is used only by BackpressureBlockTimeout.
+// Dropped entries are counted by Dropped and reported periodically as a WARN entry.
+func WithBackpressure(policy BackpressurePolicy, timeout time.Duration) LogOption {
+	return func(l *logger) {
+		l.mtx.Lock()
+		defer l.mtx.Unlock()
+		l.config.Backpressure = policy
+		l.config.BackpressureTimeout = timeout
+	}
+}
+
+// WithDropReportInterval sets the interval of the dropped entries report. The default is 10 seconds.
+func WithDropReportInterval(interval time.Duration) LogOption {
+	return func(l *logger) {
+		l.mtx.Lock()
+		defer l.mtx.Unlock()
+		l.config.DropReportInterval = interval
+	}
+}
+
+// WithAfterClose sets what happens to entries logged after Close is called. The default is AfterCloseDiscard.
+func WithAfterClose(policy AfterClosePolicy) LogOption {
+	return func(l *logger) {
+		l.mtx.Lock()
+		defer l.mtx.Unlock()
+		l.config.AfterClose = policy
+	}
+}
+
+// WithErrorHandler sets the handler called when an output fails to write an entry.
+// The default writes the errors to stderr, at most one per output every 10 seconds.
+func WithErrorHandler(handler ErrorHandler) LogOption {
+	return func(l *logger) {
+		l.mtx.Lock()
+		defer l.mtx.Unlock()
+		l.config.ErrorHandler = handler
+	}
+}
+
+// WithSyncMode writes every entry to all outputs in the goroutine that logs it, before the logging method returns.
+// There is no queue and no background goroutine, and writes to the same output are serialized by a lock.
+// It suits CLI tools and audit logs. The ...E methods return the write errors to the caller.
+func WithSyncMode() LogOption {
+	return func(l *logger) {
+		l.mtx.Lock()
+		defer l.mtx.Unlock()
+		l.config.SyncMode = true
+	}
+}
+
+// WithSyncOutput makes the outputs with the names synchronous, like WithSyncMode,
+// while the other outputs keep their queues. The built-in outputs are named "console", "file" and "remote".
+func WithSyncOutput(names ...string) LogOption {
+	return func(l *logger) {
+		l.mtx.Lock()
+		defer l.mtx.Unlock()
+		l.config.SyncOutputs = append(l.config.SyncOutputs, names...)
+	}
+}
+
+// WithReopenSignal reopens the log file whenever the process receives one of the signals, SIGHUP if none is given,
+// like Reopen. It is meant for files rotated by logrotate. The signal handler is removed by Close.
+func WithReopenSignal(signals ...os.Signal) LogOption {
+	return func(l *logger) {
+		l.mtx.Lock()
+		defer l.mtx.Unlock()
+		l.config.ReopenOnSignal = true
+		l.config.ReopenSignals = signals
+	}
+}
+
 func withOutputMode(mode OutputMode) LogOption {
 	return func(l *logger) {
 		l.mtx.Lock()
@@ -109,6 +246,179 @@ func WithRemoteMode(endpoint, method string, header http.Header, transport *http
 	}
 }
 
+// WithConsoleLevel sets the minimum level of the console output.
+// The default is NONE, which uses the level of the logger.
+func WithConsoleLevel(level LogLevel) LogOption {
+	return func(l *logger) {
+		l.mtx.Lock()
+		defer l.mtx.Unlock()
+		if l.config.ConsoleConfig == nil {
+			l.config.ConsoleConfig = &ConsoleConfig{}
+		}
+		l.config.ConsoleConfig.Level = level
+	}
+}
+
+// WithFileLevel sets the minimum level of the file output.
+// The default is NONE, which uses the level of the logger.
+// A level lower than the level of the logger lets the file receive entries that the other outputs skip.
+func WithFileLevel(level LogLevel) LogOption {
+	return func(l *logger) {
+		l.mtx.Lock()
+		defer l.mtx.Unlock()
+		if l.config.FileConfig == nil {
+			l.config.FileConfig = &FileConfig{}
+		}
+		l.config.FileConfig.Level = level
+	}
+}
+
+// WithFileMaxSize sets the size in bytes at which the log file is continued in a new numbered segment,
+// e.g. name.2024-01-01.log, name.2024-01-01.1.log, name.2024-01-01.2.log.
+// The segments restart from the first one every day or hour. The default is 0, which means no limit.
+func WithFileMaxSize(size int64) LogOption {
+	return func(l *logger) {
+		l.mtx.Lock()
+		defer l.mtx.Unlock()
+		if l.config.FileConfig == nil {
+			l.config.FileConfig = &FileConfig{}
+		}
+		l.config.FileConfig.MaxSize = size
+	}
+}
+
+// WithFileRetention removes the log files older than maxAge and the oldest log files beyond maxFiles,
+// including the segments, whenever a new log file is opened. Zero disables each limit.
+// Only the files that match the file name of the logger are removed, and never the file being written.
+func WithFileRetention(maxAge time.Duration, maxFiles int) LogOption {
+	return func(l *logger) {
+		l.mtx.Lock()
+		defer l.mtx.Unlock()
+		if l.config.FileConfig == nil {
+			l.config.FileConfig = &FileConfig{}
+		}
+		l.config.FileConfig.MaxAge = maxAge
+		l.config.FileConfig.MaxFiles = maxFiles
+	}
+}
+
+// WithFilePattern sets the pattern of the log file names and the interval of a new log file,
+// e.g. WithFilePattern("{name}-{host}-%Y%m%d-%H%M.log", 15*time.Minute).
+//
+// The placeholders {name}, {host}, {pid} and {level} are replaced with the name of the logger, the host name,
+// the process id and the level of the file output. The time directives %Y, %y, %m, %d, %H, %M, %S, %j,
+// %G, %V, %u and %% are replaced like strftime with the start of the period, and ".log" is appended if missing.
+//
+// The periods follow the wall clock of the location of the logger. Periods shorter than a day start at midnight,
+// e.g. 00:00, 00:15, 00:30 for 15 minutes, and periods of one or more whole days start at midnight,
+// with weekly periods starting on Monday. An empty pattern uses the pattern of the file create mode,
+// or "{name}.%Y-%m-%d-%H%M.log" if only the interval is set, and a zero interval uses the file create mode.
+// NewLogger returns ErrFileInterval for an interval of a day or more that is not whole days.
+// With {pid}, the compression and the maxFiles of WithFileRetention only touch the files of the current process,
+// while the maxAge of WithFileRetention also removes the files of previous processes.
+func WithFilePattern(pattern string, interval time.Duration) LogOption {
+	return func(l *logger) {
+		l.mtx.Lock()
+		defer l.mtx.Unlock()
+		if l.config.FileConfig == nil {
+			l.config.FileConfig = &FileConfig{}
+		}
+		l.config.FileConfig.Pattern = pattern
+		l.config.FileConfig.Interval = interval
+	}
+}
+
+// WithFileCompress compresses the previous log files to .log.gz in the background whenever a new log file is opened.
+// A file is compressed to a temporary file that is renamed when it is complete, and the temporary files
+// left by a process that stopped while compressing are removed when the first log file is opened.
+func WithFileCompress() LogOption {
+	return func(l *logger) {
+		l.mtx.Lock()
+		defer l.mtx.Unlock()
+		if l.config.FileConfig == nil {
+			l.config.FileConfig = &FileConfig{}
+		}
+		l.config.FileConfig.Compress = true
+	}
+}
+
+// WithFileSymlinkOff disables the symlink <logPath>/<fileName>.log that points at the log file being written.
+// By default the symlink is replaced atomically whenever a new log file is opened, so tail -F can follow it.
+func WithFileSymlinkOff() LogOption {
+	return func(l *logger) {
+		l.mtx.Lock()
+		defer l.mtx.Unlock()
+		if l.config.FileConfig == nil {
+			l.config.FileConfig = &FileConfig{}
+		}
+		l.config.FileConfig.DisableSymlink = true
+	}
+}
+
+// WithFileBuffer buffers the writes to the log file in a buffer of size bytes.
+// The buffer is flushed every flushInterval, when the file rotates, when the logger is closed or flushed,
+// and after every entry at or above ERROR. The default is no buffer, and the default flush interval is 1 second.
+func WithFileBuffer(size int, flushInterval time.Duration) LogOption {
+	return func(l *logger) {
+		l.mtx.Lock()
+		defer l.mtx.Unlock()
+		if l.config.FileConfig == nil {
+			l.config.FileConfig = &FileConfig{}
+		}
+		l.config.FileConfig.BufferSize = size
+		l.config.FileConfig.FlushInterval = flushInterval
+	}
+}
+
+// WithFileFsync sets when the log file is synced to the disk. The default is FsyncNever.
+// FsyncInterval uses the flush interval of WithFileBuffer, which also applies to an unbuffered file.
+// The file is also synced when it rotates and when the logger is closed or flushed, unless the policy is FsyncNever.
+func WithFileFsync(policy FsyncPolicy) LogOption {
+	return func(l *logger) {
+		l.mtx.Lock()
+		defer l.mtx.Unlock()
+		if l.config.FileConfig == nil {
+			l.config.FileConfig = &FileConfig{}
+		}
+		l.config.FileConfig.Fsync = policy
+	}
+}
+
+// WithRemoteLevel sets the minimum level of the remote output.
+// The default is NONE, which uses the level of the logger.
+func WithRemoteLevel(level LogLevel) LogOption {
+	return func(l *logger) {
+		l.mtx.Lock()
+		defer l.mtx.Unlock()
+		if l.config.RemoteConfig == nil {
+			l.config.RemoteConfig = &RemoteConfig{}
+		}
+		l.config.RemoteConfig.Level = level
+	}
+}
+
+// WithWriter registers a custom output that receives every entry alongside the built-in outputs.
+// If formatter is nil, the writer receives the raw entry. Otherwise the writer receives the output
+// of formatter as the format "%s" and a single argument, with the fields of the entry.
+// If the writer implements io.Closer, it is closed by Close.
+// A writer registered with the same name replaces the previous one.
+// The names "console", "file" and "remote" are reserved and NewLogger returns ErrWriterName for them.
+// NewLogger returns ErrNilWriter if w is nil.
+func WithWriter(name string, w Writer, formatter Formatter) LogOption {
+	return func(l *logger) {
+		l.mtx.Lock()
+		defer l.mtx.Unlock()
+		config := &WriterConfig{Name: name, Writer: w, Formatter: formatter}
+		for i, registered := range l.config.Writers {
+			if registered.Name == name {
+				l.config.Writers[i] = config
+				return
+			}
+		}
+		l.config.Writers = append(l.config.Writers, config)
+	}
+}
+
 // WithConsoleModeOff sets the console mode of the logger to off.
 func WithConsoleModeOff() LogOption {
 	return func(l *logger) {
@@ -169,3 +479,13 @@ func WithRegisterFormatter(formatterRegister *FormatterRegistry) LogOption {
 		l.config.FormatterRegistry = formatterRegister
 	}
 }
+
+// WithContextExtractor adds extractors that attach fields from the context to entries
+// logged with the ...Ctx methods. The extractors are called in the order they are added.
+func WithContextExtractor(extractors ...ContextExtractor) LogOption {
+	return func(l *logger) {
+		l.mtx.Lock()
+		defer l.mtx.Unlock()
+		l.config.ContextExtractors = append(l.config.ContextExtractors, extractors...)
+	}
+}
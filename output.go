@@ -0,0 +1,158 @@
+package log
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// The methods in this file change the outputs of a running logger.
+// The changes are applied in order with the entries: entries logged before a change
+// are written to the previous outputs, and entries logged after it to the new outputs.
+// A removed output receives every entry queued before the removal and is then closed.
+
+// AddOutput registers a custom output on the running logger. See WithWriter for the writer and formatter.
+// It returns ErrWriterName if the name is reserved, ErrNilWriter if w is nil
+// and ErrOutputExists if an output with the name exists.
+func (l *logger) AddOutput(name string, w Writer, formatter Formatter) error {
+	switch name {
+	case "", consoleOutputName, fileOutputName, remoteOutputName:
+		return fmt.Errorf("%w: %q", ErrWriterName, name)
+	}
+	if w == nil {
+		return fmt.Errorf("%w: %q", ErrNilWriter, name)
+	}
+	return l.addOutput(name, newCustomWriter(w, formatter), NONE)
+}
+
+// RemoveOutput removes the output with the name from the running logger after writing
+// the queued entries to it, and closes it if it implements io.Closer.
+// The built-in outputs are named "console", "file" and "remote".
+// It returns ErrOutputNotFound if there is no output with the name.
+func (l *logger) RemoveOutput(name string) error {
+	err := l.dynamicWriter.do(func() error {
+		output := l.dynamicWriter.removeOutput(name)
+		if output == nil {
+			return fmt.Errorf("%w: %q", ErrOutputNotFound, name)
+		}
+		return output.stop()
+	})
+	if err != nil {
+		return err
+	}
+	if mode, ok := outputModes[name]; ok {
+		l.mtx.Lock()
+		l.config.OutputMode &= ^mode
+		l.mtx.Unlock()
+	}
+	return nil
+}
+
+// EnableConsoleMode starts writing to the console on the running logger.
+// The Enable methods return ErrOutputExists if the output is already enabled.
+func (l *logger) EnableConsoleMode() error {
+	l.mtx.Lock()
+	if l.config.OutputMode&OutputModeConsole != 0 {
+		l.mtx.Unlock()
+		return fmt.Errorf("%w: %q", ErrOutputExists, consoleOutputName)
+	}
+	l.root.initConsoleConfig()
+	writer := newConsoleWriter(l.root)
+	level := l.config.ConsoleConfig.Level
+	l.mtx.Unlock()
+	return l.addBuiltinOutput(consoleOutputName, writer, level)
+}
+
+// DisableConsoleMode stops writing to the console on the running logger.
+func (l *logger) DisableConsoleMode() error {
+	return l.RemoveOutput(consoleOutputName)
+}
+
+// EnableFileMode starts writing to the file on the running logger.
+// The arguments are the same as WithFileMode.
+func (l *logger) EnableFileMode(fileName, logPath string, mode FileCreateMode) error {
+	l.mtx.Lock()
+	if l.config.OutputMode&OutputModeFile != 0 {
+		l.mtx.Unlock()
+		return fmt.Errorf("%w: %q", ErrOutputExists, fileOutputName)
+	}
+	if l.config.FileConfig == nil {
+		l.config.FileConfig = &FileConfig{}
+	}
+	l.config.FileConfig.FileName = fileName
+	l.config.FileConfig.LogPath = logPath
+	l.config.FileConfig.FileCreateMode = mode
+	l.root.initFileConfig()
+	writer, err := newFileWriter(l.root)
+	level := l.config.FileConfig.Level
+	l.mtx.Unlock()
+	if err != nil {
+		return err
+	}
+	return l.addBuiltinOutput(fileOutputName, writer, level)
+}
+
+// DisableFileMode stops writing to the file on the running logger and closes the file.
+func (l *logger) DisableFileMode() error {
+	return l.RemoveOutput(fileOutputName)
+}
+
+// EnableRemoteMode starts sending entries to the remote endpoint on the running logger.
+// The arguments are the same as WithRemoteMode.
+func (l *logger) EnableRemoteMode(endpoint, method string, header http.Header, transport *http.RoundTripper) error {
+	l.mtx.Lock()
+	if l.config.OutputMode&OutputModeRemote != 0 {
+		l.mtx.Unlock()
+		return fmt.Errorf("%w: %q", ErrOutputExists, remoteOutputName)
+	}
+	if l.config.RemoteConfig == nil {
+		l.config.RemoteConfig = &RemoteConfig{}
+	}
+	l.config.RemoteConfig.EndPoint = endpoint
+	l.config.RemoteConfig.Method = method
+	l.config.RemoteConfig.Header = header
+	l.config.RemoteConfig.Transport = transport
+	if err := l.root.initRemoteConfig(); err != nil {
+		l.mtx.Unlock()
+		return err
+	}
+	writer := newRemoteWriter(l.root)
+	level := l.config.RemoteConfig.Level
+	l.mtx.Unlock()
+	return l.addBuiltinOutput(remoteOutputName, writer, level)
+}
+
+// DisableRemoteMode stops sending entries on the running logger after the in-flight requests finish.
+func (l *logger) DisableRemoteMode() error {
+	return l.RemoveOutput(remoteOutputName)
+}
+
+// outputModes maps the names of the built-in outputs to their OutputMode.
+var outputModes = map[string]OutputMode{
+	consoleOutputName: OutputModeConsole,
+	fileOutputName:    OutputModeFile,
+	remoteOutputName:  OutputModeRemote,
+}
+
+func (l *logger) addBuiltinOutput(name string, writer Writer, level LogLevel) error {
+	if err := l.addOutput(name, writer, level); err != nil {
+		if c, ok := writer.(io.Closer); ok {
+			_ = c.Close()
+		}
+		return err
+	}
+	l.mtx.Lock()
+	l.config.OutputMode |= outputModes[name]
+	l.mtx.Unlock()
+	return nil
+}
+
+func (l *logger) addOutput(name string, writer Writer, level LogLevel) error {
+	return l.dynamicWriter.do(func() error {
+		if l.dynamicWriter.hasOutput(name) {
+			return fmt.Errorf("%w: %q", ErrOutputExists, name)
+		}
+		l.dynamicWriter.addOutput(name, writer, level)
+		return nil
+	})
+}
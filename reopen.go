@@ -0,0 +1,112 @@
+package log
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// reopener is implemented by writers that write to a file that can be rotated by another program, e.g. logrotate.
+// Reopen closes the file, and the next Write opens the file by its name again.
+type reopener interface {
+	Reopen() error
+}
+
+// fileCheckInterval is the minimum interval between two checks of the file writer
+// whether its file was removed or moved by another program.
+const fileCheckInterval = time.Second
+
+// Reopen closes and reopens the log file after the entries logged before the call are written,
+// so that a file moved or removed by logrotate is created again. Custom writers that implement
+// Reopen() error are reopened as well. It returns the errors of the outputs,
+// or an error that wraps ctx.Err() and lists the outputs with unwritten entries if ctx is done first.
+func (l *logger) Reopen(ctx context.Context) error {
+	return l.dynamicWriter.reopen(ctx)
+}
+
+func (d *dynamicWriter) reopen(ctx context.Context) error {
+	return d.eachOutput(ctx, func(o *output) error {
+		if err := reopenOutput(o); err != nil {
+			return fmt.Errorf("output %q: %w", o.name, err)
+		}
+		return nil
+	})
+}
+
+// reopenOutput reopens the writer of the output if it implements reopener.
+func reopenOutput(o *output) error {
+	if r, ok := o.writer.(reopener); ok {
+		return r.Reopen()
+	}
+	return nil
+}
+
+// notifyReopen reopens the outputs whenever one of the signals is received, until the dynamic writer is closed.
+// SIGHUP is used if no signal is given. The errors are reported to the ErrorHandler.
+// Closing cancels a reopen that waits for a stuck output, so Close does not wait for it.
+func (d *dynamicWriter) notifyReopen(signals []os.Signal) {
+	if len(signals) == 0 {
+		signals = []os.Signal{syscall.SIGHUP}
+	}
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, signals...)
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		for {
+			select {
+			case <-ch:
+				_ = d.eachOutput(ctx, func(o *output) error {
+					if err := reopenOutput(o); err != nil {
+						d.handleError(o, err)
+					}
+					return nil
+				})
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	d.stopSignals = func() {
+		signal.Stop(ch)
+		cancel()
+		<-stopped
+	}
+}
+
+// Reopen flushes and closes the file. The next Write opens the file by its name again.
+func (f *fileWriter) Reopen() error {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	return f.closeLocked()
+}
+
+// checkFile closes the file if it was removed or moved since it was opened,
+// so the next write creates it again instead of writing to a file that nobody reads.
+// If the file was truncated, e.g. by logrotate copytruncate, the tracked size is reset to the size of the file,
+// so FileConfig.MaxSize does not start a new segment too early.
+// It checks at most once every fileCheckInterval, so the file is not stat-ed on every write. f.mtx must be held.
+func (f *fileWriter) checkFile() {
+	if f.file == nil || time.Since(f.checked) < fileCheckInterval {
+		return
+	}
+	f.checked = time.Now()
+	info, err := os.Stat(f.currentFileName)
+	if err == nil && os.SameFile(info, f.info) {
+		var buffered int64
+		if f.buf != nil {
+			buffered = int64(f.buf.Buffered())
+		}
+		if info.Size() < f.size-buffered {
+			f.size = info.Size() + buffered
+		}
+		return
+	}
+	if err := f.closeLocked(); err != nil {
+		f.handleError(err)
+	}
+}
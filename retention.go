@@ -0,0 +1,149 @@
+package log
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// janitor runs the housekeeping of a fileWriter in a background goroutine, so rotation does not wait for it.
+// A run requested while one is in progress is done again after it.
+type janitor struct {
+	mtx     sync.Mutex
+	wg      sync.WaitGroup
+	running bool
+	again   bool
+}
+
+// run calls fn in the background unless a run is in progress.
+func (j *janitor) run(fn func()) {
+	j.mtx.Lock()
+	defer j.mtx.Unlock()
+	if j.running {
+		j.again = true
+		return
+	}
+	j.running = true
+	j.wg.Add(1)
+	go func() {
+		defer j.wg.Done()
+		for {
+			j.mtx.Lock()
+			j.again = false
+			j.mtx.Unlock()
+
+			fn()
+
+			j.mtx.Lock()
+			if !j.again {
+				j.running = false
+				j.mtx.Unlock()
+				return
+			}
+			j.mtx.Unlock()
+		}
+	}()
+}
+
+// wait waits for the run in progress.
+func (j *janitor) wait() {
+	j.wg.Wait()
+}
+
+// housekeeping compresses the rotated log files and removes the expired ones. It runs in the janitor goroutine.
+// The first run also cleans up after a compression interrupted by a previous process.
+// The files are scanned while the writer keeps writing, so each file is checked with rotated before it is touched.
+func (f *fileWriter) housekeeping() {
+	if f.compress {
+		if !f.recovered {
+			f.recoverCompression()
+			f.recovered = true
+		}
+		f.compressRotated()
+	}
+	if f.maxAge > 0 || f.maxFiles > 0 {
+		f.removeExpired()
+	}
+}
+
+// removeExpired removes the log files older than maxAge and the oldest log files beyond maxFiles.
+// The active file is never removed and counts towards maxFiles.
+// With {pid} in the pattern, the files of the previous processes are removed by maxAge only,
+// and maxFiles counts the files of the current process.
+// The errors are reported to the error handler of the writer.
+func (f *fileWriter) removeExpired() {
+	entries, err := os.ReadDir(f.logPath)
+	if err != nil {
+		f.handleError(err)
+		return
+	}
+	type logFile struct {
+		path    string
+		modTime time.Time
+		own     bool // written by the current process
+	}
+	var files []logFile
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() || !f.pattern.matchesAnyPid(entry.Name()) {
+			continue
+		}
+		path := filepath.Join(f.logPath, entry.Name())
+		own := f.isLogFile(entry.Name())
+		if own && !f.rotated(path) {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		files = append(files, logFile{path: path, modTime: info.ModTime(), own: own})
+	}
+	// newest first
+	sort.Slice(files, func(i, j int) bool {
+		if files[i].modTime.Equal(files[j].modTime) {
+			return files[i].path > files[j].path
+		}
+		return files[i].modTime.After(files[j].modTime)
+	})
+
+	var errs []error
+	kept := 1 // the active file is the first one
+	for _, file := range files {
+		expired := f.maxAge > 0 && time.Since(file.modTime) > f.maxAge
+		excess := false
+		if file.own {
+			excess = f.maxFiles > 0 && kept >= f.maxFiles
+			kept++
+		}
+		if !expired && !excess {
+			continue
+		}
+		if err := os.Remove(file.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			errs = append(errs, err)
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		f.handleError(err)
+	}
+}
+
+// rotated reports whether the file at path is not the file being written. Every file is treated as active
+// while the writer has no file open, since the next Write may open any segment of the period again.
+func (f *fileWriter) rotated(path string) bool {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	return f.file != nil && path != f.currentFileName
+}
+
+func (f *fileWriter) setErrorHandler(handler func(err error)) {
+	f.errorHandler = handler
+}
+
+func (f *fileWriter) handleError(err error) {
+	if f.errorHandler != nil {
+		f.errorHandler(err)
+	}
+}
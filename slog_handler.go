@@ -0,0 +1,134 @@
+package log
+
+import (
+	"context"
+	"log/slog"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// slogHandler is a slog.Handler that writes slog records through the logger.
+// Attributes become fields. Groups are flattened into dotted keys, e.g. "request.id".
+// The context extractors of the logger are applied to the context passed to Handle.
+type slogHandler struct {
+	l      *logger
+	prefix string
+	attrs  []Field
+}
+
+// NewSlogHandler returns a slog.Handler that writes records to the outputs of the logger.
+// The handler uses the level, formatters and outputs of the logger.
+//
+// example:
+//
+//	mlog, _ := log.NewLogger("my-app")
+//	slogger := slog.New(log.NewSlogHandler(mlog))
+//	slogger.Info("Hello, World!", "user", "winey")
+func NewSlogHandler(l *logger) slog.Handler {
+	return &slogHandler{l: l}
+}
+
+// slogLevel converts a slog level to the nearest LogLevel.
+func slogLevel(level slog.Level) LogLevel {
+	switch {
+	case level >= slog.LevelError:
+		return ERROR
+	case level >= slog.LevelWarn:
+		return WARN
+	case level >= slog.LevelInfo:
+		return INFO
+	case level >= slog.LevelDebug:
+		return DEBUG
+	default:
+		return TRACE
+	}
+}
+
+func (h *slogHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return h.l.enabled(slogLevel(level))
+}
+
+func (h *slogHandler) Handle(ctx context.Context, r slog.Record) error {
+	fields := make([]Field, 0, len(h.l.fields)+len(h.attrs)+r.NumAttrs())
+	fields = append(fields, h.l.fields...)
+	fields = append(fields, h.l.contextFields(ctx)...)
+	fields = append(fields, h.attrs...)
+	r.Attrs(func(attr slog.Attr) bool {
+		fields = appendAttr(fields, h.prefix, attr)
+		return true
+	})
+	if h.l.config.Caller && r.PC != 0 {
+		if caller, ok := callerFieldFromPC(r.PC); ok {
+			fields = append(fields, caller)
+		}
+	}
+
+	level := slogLevel(r.Level)
+	if h.l.config.StacktraceLevel != NONE && level >= h.l.config.StacktraceLevel {
+		fields = append(fields, h.stacktraceField(r, fields))
+	}
+
+	t := r.Time
+	if t.IsZero() {
+		t = time.Now()
+	}
+	msg := r.Message
+	if !strings.HasSuffix(msg, "\n") {
+		msg += "\n"
+	}
+	h.l.logAt(t, level, h.l.level(), fields, "%s", msg)
+	return nil
+}
+
+func (h *slogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+	fields := make([]Field, 0, len(h.attrs)+len(attrs))
+	fields = append(fields, h.attrs...)
+	for _, attr := range attrs {
+		fields = appendAttr(fields, h.prefix, attr)
+	}
+	return &slogHandler{l: h.l, prefix: h.prefix, attrs: fields}
+}
+
+func (h *slogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	return &slogHandler{l: h.l, prefix: h.prefix + name + ".", attrs: h.attrs}
+}
+
+// stacktraceField returns the stacktrace field of the code that called the slog logger.
+// The frames of log/slog are skipped by starting the stack at the frame of the record.
+func (h *slogHandler) stacktraceField(r slog.Record, fields []Field) Field {
+	pcs := make([]uintptr, maxStackDepth)
+	pcs = pcs[:runtime.Callers(2, pcs)]
+	for i, pc := range pcs {
+		if pc == r.PC {
+			pcs = pcs[i:]
+			break
+		}
+	}
+	return newStacktraceField(pcs, fields, nil)
+}
+
+// appendAttr appends the attribute as a field.
+// Group attributes are flattened and empty attributes are ignored as slog requires.
+func appendAttr(fields []Field, prefix string, attr slog.Attr) []Field {
+	attr.Value = attr.Value.Resolve()
+	if attr.Equal(slog.Attr{}) {
+		return fields
+	}
+	if attr.Value.Kind() == slog.KindGroup {
+		if attr.Key != "" {
+			prefix += attr.Key + "."
+		}
+		for _, groupAttr := range attr.Value.Group() {
+			fields = appendAttr(fields, prefix, groupAttr)
+		}
+		return fields
+	}
+	return append(fields, Field{Key: prefix + attr.Key, Value: attr.Value.Any()})
+}
@@ -0,0 +1,106 @@
+package log
+
+import (
+	"errors"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// StacktraceKey is the key of the field that holds the Stacktrace of an entry.
+// The field is attached when the logger is created with WithStacktrace and is always the last field of the entry.
+const StacktraceKey = "stacktrace"
+
+// maxStackDepth is the maximum number of frames in a stack trace.
+const maxStackDepth = 64
+
+// Stacktrace is the stack of the goroutine that logged an entry.
+// When errors that wrap other errors are passed as args or fields,
+// their chains are written before the stack as "caused by: ..." lines.
+//
+// example:
+//
+//	caused by: dial tcp: connection refused
+//	main.connect
+//		/app/main.go:42
+//	main.main
+//		/app/main.go:10
+type Stacktrace string
+
+// stacktraceField returns the stacktrace field of the code that called the logging method.
+func (l *logger) stacktraceField(fields []Field, args []any) Field {
+	pcs := make([]uintptr, maxStackDepth)
+	n := runtime.Callers(callerSkip+l.config.CallerSkip, pcs)
+	return newStacktraceField(pcs[:n], fields, args)
+}
+
+func newStacktraceField(pcs []uintptr, fields []Field, args []any) Field {
+	var sb strings.Builder
+	for _, arg := range args {
+		if err, ok := arg.(error); ok {
+			writeErrorChain(&sb, err)
+		}
+	}
+	for _, field := range fields {
+		if err, ok := field.Value.(error); ok {
+			writeErrorChain(&sb, err)
+		}
+	}
+
+	frames := runtime.CallersFrames(pcs)
+	for {
+		frame, more := frames.Next()
+		if frame.PC != 0 {
+			sb.WriteString(frame.Function)
+			sb.WriteString("\n\t")
+			sb.WriteString(frame.File)
+			sb.WriteByte(':')
+			sb.WriteString(strconv.Itoa(frame.Line))
+			sb.WriteByte('\n')
+		}
+		if !more {
+			break
+		}
+	}
+	return Field{Key: StacktraceKey, Value: Stacktrace(strings.TrimSuffix(sb.String(), "\n"))}
+}
+
+// writeErrorChain writes the errors wrapped by err, one per line.
+// Nothing is written when err does not wrap another error.
+func writeErrorChain(sb *strings.Builder, err error) {
+	for _, cause := range unwrapAll(err) {
+		sb.WriteString("caused by: ")
+		sb.WriteString(cause.Error())
+		sb.WriteByte('\n')
+	}
+}
+
+// unwrapAll returns all errors wrapped by err in depth-first order.
+// It supports both Unwrap() error and Unwrap() []error.
+func unwrapAll(err error) []error {
+	var causes []error
+	switch e := err.(type) {
+	case interface{ Unwrap() []error }:
+		for _, cause := range e.Unwrap() {
+			if cause != nil {
+				causes = append(causes, cause)
+				causes = append(causes, unwrapAll(cause)...)
+			}
+		}
+	default:
+		if cause := errors.Unwrap(err); cause != nil {
+			causes = append(causes, cause)
+			causes = append(causes, unwrapAll(cause)...)
+		}
+	}
+	return causes
+}
+
+// writeStacktrace writes the stack trace as lines indented with a tab.
+func writeStacktrace(sb *strings.Builder, stacktrace Stacktrace) {
+	for _, line := range strings.Split(string(stacktrace), "\n") {
+		sb.WriteByte('\t')
+		sb.WriteString(line)
+		sb.WriteByte('\n')
+	}
+}
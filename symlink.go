@@ -0,0 +1,40 @@
+package log
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// updateSymlink points the symlink of the writer at the active file, e.g. name.log -> name.2024-05-01-13.log.
+// The symlink is replaced atomically by renaming a new symlink over it, so readers always see a valid link.
+// A regular file at the path of the symlink is moved aside to name.log.<time>.old instead of being replaced.
+// The errors are reported to the error handler of the writer, but a file moved aside is not an error.
+func (f *fileWriter) updateSymlink(active string) {
+	if f.symlink == "" || f.symlink == active {
+		return
+	}
+	if info, err := os.Lstat(f.symlink); err == nil && info.Mode()&os.ModeSymlink == 0 {
+		if info.IsDir() {
+			f.handleError(fmt.Errorf("symlink %s: is a directory", f.symlink))
+			return
+		}
+		stale := fmt.Sprintf("%s.%s.old", f.symlink, time.Now().Format("20060102-150405"))
+		if err := os.Rename(f.symlink, stale); err != nil {
+			f.handleError(fmt.Errorf("symlink %s: %w", f.symlink, err))
+			return
+		}
+	}
+
+	tmp := fmt.Sprintf("%s.%d.tmp", f.symlink, os.Getpid())
+	_ = os.Remove(tmp)
+	if err := os.Symlink(filepath.Base(active), tmp); err != nil {
+		f.handleError(err)
+		return
+	}
+	if err := os.Rename(tmp, f.symlink); err != nil {
+		_ = os.Remove(tmp)
+		f.handleError(err)
+	}
+}
@@ -0,0 +1,40 @@
+package log
+
+import (
+	"errors"
+	"fmt"
+)
+
+// writeSync writes the entry to the synchronous outputs whose level allows it in the calling goroutine.
+// The errors are reported to the ErrorHandler and returned joined.
+func (d *dynamicWriter) writeSync(entry *logEntry) error {
+	d.mtx.RLock()
+	defer d.mtx.RUnlock()
+	var errs []error
+	for _, output := range d.writers {
+		if !output.sync || !output.accepts(entry) {
+			continue
+		}
+		output.mtx.Lock()
+		_, err := output.writer.Write(entry.t, entry.level, entry.fields, entry.format, entry.args...)
+		output.mtx.Unlock()
+		if err != nil {
+			d.handleError(output, err)
+			errs = append(errs, fmt.Errorf("output %q: %w", output.name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// doSync runs op in the calling goroutine while the dynamic writer is running.
+// It is used instead of the dispatcher goroutine in sync mode.
+func (d *dynamicWriter) doSync(op func() error) error {
+	d.sendMtx.RLock()
+	defer d.sendMtx.RUnlock()
+	if d.state.Load() != stateRunning {
+		return ErrClosed
+	}
+	d.opMtx.Lock()
+	defer d.opMtx.Unlock()
+	return op()
+}
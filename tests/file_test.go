@@ -0,0 +1,650 @@
+package tests
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/winey-dev/go-log"
+)
+
+// messageFormatter writes only the message, so the tests control the size of every entry.
+func messageFormatter(t time.Time, level log.LogLevel, fields []log.Field, format string, args ...any) string {
+	return fmt.Sprintf(format, args...)
+}
+
+func TestLogFileName(t *testing.T) {
+	dir := t.TempDir()
+	// the log files are named after the logger, and the file name of WithFileMode is not used
+	mlog, err := log.NewLogger("test", log.WithConsoleModeOff(), log.WithFileMode("other", dir, log.DAILYMODE))
+	if err != nil {
+		t.Fatal(err)
+	}
+	mlog.Info("Test Info\n")
+	mlog.Close()
+
+	if _, err := os.Stat(filepath.Join(dir, "test."+time.Now().Format(time.DateOnly)+".log")); err != nil {
+		t.Errorf("expected the file to be named after the logger: %v", err)
+	}
+	if _, err := os.Lstat(filepath.Join(dir, "test.log")); err != nil {
+		t.Errorf("expected the symlink to be named after the logger: %v", err)
+	}
+}
+
+func TestLogFileMaxSize(t *testing.T) {
+	dir := t.TempDir()
+	newLogger := func() interface {
+		Info(format string, args ...any)
+		Close()
+	} {
+		mlog, err := log.NewLogger("test",
+			log.WithConsoleModeOff(),
+			log.WithFileMode("", dir, log.DAILYMODE),
+			log.WithFileModeFormatter(messageFormatter),
+			log.WithFileMaxSize(100),
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return mlog
+	}
+
+	mlog := newLogger()
+	for i := 0; i < 5; i++ {
+		mlog.Info("%039d\n", i) // 40 bytes, two entries per segment
+	}
+	mlog.Close()
+
+	base := filepath.Join(dir, "test."+time.Now().Format(time.DateOnly))
+	for _, segment := range []string{".log", ".1.log", ".2.log"} {
+		if _, err := os.Stat(base + segment); err != nil {
+			t.Errorf("expected segment %s: %v", segment, err)
+		}
+	}
+
+	// a restarted logger continues the last segment
+	mlog = newLogger()
+	mlog.Info("%039d\n", 5)
+	mlog.Info("%039d\n", 6)
+	mlog.Close()
+
+	if dat, _ := os.ReadFile(base + ".2.log"); len(dat) != 80 {
+		t.Errorf("expected 2 entries in the last segment, got %q", dat)
+	}
+	if dat, _ := os.ReadFile(base + ".3.log"); len(dat) != 40 {
+		t.Errorf("expected 1 entry in a new segment, got %q", dat)
+	}
+}
+
+func TestLogFileRetention(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		maxAge   time.Duration
+		maxFiles int
+		kept     []string
+	}{
+		{name: "MaxFiles", maxFiles: 3, kept: []string{"test.2024-01-02.log", "test.2024-01-03.1.log"}},
+		{name: "MaxAge", maxAge: 36 * time.Hour, kept: []string{"test.2024-01-03.1.log"}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			old := []string{"test.2024-01-01.log", "test.2024-01-02.log", "test.2024-01-03.1.log"}
+			unrelated := []string{"other.2024-01-01.log", "test.notes.log", "test.2024-01-01.log.bak"}
+			for i, name := range append(old, unrelated...) {
+				path := filepath.Join(dir, name)
+				if err := os.WriteFile(path, []byte("old\n"), 0644); err != nil {
+					t.Fatal(err)
+				}
+				modTime := time.Now().Add(-time.Duration(72-i*24) * time.Hour)
+				if err := os.Chtimes(path, modTime, modTime); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			mlog, err := log.NewLogger("test",
+				log.WithConsoleModeOff(),
+				log.WithFileMode("", dir, log.DAILYMODE),
+				log.WithFileRetention(tc.maxAge, tc.maxFiles),
+			)
+			if err != nil {
+				t.Fatal(err)
+			}
+			mlog.Info("Test Info\n")
+			mlog.Close()
+
+			for _, name := range old {
+				_, err := os.Stat(filepath.Join(dir, name))
+				kept := slices.Contains(tc.kept, name)
+				if kept && err != nil {
+					t.Errorf("expected %s to be kept: %v", name, err)
+				}
+				if !kept && err == nil {
+					t.Errorf("expected %s to be removed", name)
+				}
+			}
+			for _, name := range append(unrelated, "test."+time.Now().Format(time.DateOnly)+".log") {
+				if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+					t.Errorf("expected %s to be kept: %v", name, err)
+				}
+			}
+		})
+	}
+}
+
+func TestLogFileCompress(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	gzipped := func(content string) string {
+		var buf bytes.Buffer
+		gz := gzip.NewWriter(&buf)
+		_, _ = gz.Write([]byte(content))
+		_ = gz.Close()
+		return buf.String()
+	}
+	// not compressed yet
+	write("test.2024-01-01.log", "day 1\n")
+	// stopped while compressing
+	write("test.2024-01-02.log", "day 2\n")
+	write("test.2024-01-02.log.gz.tmp", "partial")
+	// stopped after compressing but before removing the file
+	write("test.2024-01-03.log", "day 3\n")
+	write("test.2024-01-03.log.gz", gzipped("day 3\n"))
+
+	mlog, err := log.NewLogger("test",
+		log.WithConsoleModeOff(),
+		log.WithFileMode("", dir, log.DAILYMODE),
+		log.WithFileModeFormatter(messageFormatter),
+		log.WithFileMaxSize(10),
+		log.WithFileCompress(),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mlog.Info("today 1\n")
+	mlog.Info("today 2\n")
+	mlog.Close()
+
+	today := "test." + time.Now().Format(time.DateOnly)
+	for name, content := range map[string]string{
+		"test.2024-01-01.log.gz": "day 1\n",
+		"test.2024-01-02.log.gz": "day 2\n",
+		"test.2024-01-03.log.gz": "day 3\n",
+		today + ".log.gz":        "today 1\n",
+	} {
+		file, err := os.Open(filepath.Join(dir, name))
+		if err != nil {
+			t.Errorf("expected %s: %v", name, err)
+			continue
+		}
+		gz, err := gzip.NewReader(file)
+		if err != nil {
+			t.Errorf("%s: %v", name, err)
+			file.Close()
+			continue
+		}
+		dat, _ := io.ReadAll(gz)
+		file.Close()
+		if string(dat) != content {
+			t.Errorf("unexpected content of %s: %q", name, dat)
+		}
+	}
+	files, _ := filepath.Glob(filepath.Join(dir, "*"))
+	if len(files) != 6 {
+		t.Errorf("expected the compressed files, the active file and the symlink, got %v", files)
+	}
+	if dat, _ := os.ReadFile(filepath.Join(dir, today+".1.log")); string(dat) != "today 2\n" {
+		t.Errorf("unexpected content of the active file: %q", dat)
+	}
+}
+
+func TestLogFilePattern(t *testing.T) {
+	host, _ := os.Hostname()
+	kst := time.FixedZone("KST", 9*60*60)
+	for _, tc := range []struct {
+		name     string
+		pattern  string
+		interval time.Duration
+		times    []time.Time
+		files    map[string]int
+	}{
+		{
+			name:     "15 minutes",
+			pattern:  "{name}-{host}-%Y%m%d-%H%M.log",
+			interval: 15 * time.Minute,
+			times: []time.Time{
+				time.Date(2024, 5, 1, 10, 7, 0, 0, kst),
+				time.Date(2024, 5, 1, 10, 14, 59, 0, kst),
+				time.Date(2024, 5, 1, 10, 15, 0, 0, kst),
+				time.Date(2024, 5, 1, 1, 31, 0, 0, time.UTC), // 10:31 KST
+			},
+			files: map[string]int{
+				"test-" + host + "-20240501-1000.log": 2,
+				"test-" + host + "-20240501-1015.log": 1,
+				"test-" + host + "-20240501-1030.log": 1,
+			},
+		},
+		{
+			name:     "weekly",
+			pattern:  "{name}.{level}.%G-W%V",
+			interval: 7 * 24 * time.Hour,
+			times: []time.Time{
+				time.Date(2024, 4, 29, 0, 0, 0, 0, kst),  // Monday
+				time.Date(2024, 5, 5, 23, 59, 0, 0, kst), // Sunday
+				time.Date(2024, 5, 5, 15, 0, 0, 0, time.UTC),
+			},
+			files: map[string]int{
+				"test.INFO.2024-W18.log": 2,
+				"test.INFO.2024-W19.log": 1,
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			mlog, err := log.NewLogger("test",
+				log.WithConsoleModeOff(),
+				log.WithLocation(kst),
+				log.WithFileMode("", dir, log.DAILYMODE),
+				log.WithFilePattern(tc.pattern, tc.interval),
+				log.WithFileSymlinkOff(),
+			)
+			if err != nil {
+				t.Fatal(err)
+			}
+			handler := log.NewSlogHandler(mlog)
+			for _, at := range tc.times {
+				_ = handler.Handle(context.Background(), slog.NewRecord(at, slog.LevelInfo, "Test Info", 0))
+			}
+			mlog.Close()
+
+			// NewLogger also creates the file of the current period
+			files, _ := filepath.Glob(filepath.Join(dir, "*2024*"))
+			if len(files) != len(tc.files) {
+				t.Errorf("expected %d files, got %v", len(tc.files), files)
+			}
+			for name, lines := range tc.files {
+				dat, err := os.ReadFile(filepath.Join(dir, name))
+				if err != nil {
+					t.Errorf("expected %s: %v", name, err)
+					continue
+				}
+				if n := strings.Count(string(dat), "\n"); n != lines {
+					t.Errorf("expected %d lines in %s, got %d", lines, name, n)
+				}
+			}
+		})
+	}
+}
+
+func TestLogFilePatternPid(t *testing.T) {
+	dir := t.TempDir()
+	other := filepath.Join(dir, "test.1.log") // the file of a process with pid 1
+	if err := os.WriteFile(other, []byte("other process\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	old := time.Now().Add(-time.Hour)
+	_ = os.Chtimes(other, old, old)
+	expired := filepath.Join(dir, "test.2.log") // the file of a previous process with pid 2
+	if err := os.WriteFile(expired, []byte("previous process\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	old = time.Now().Add(-48 * time.Hour)
+	_ = os.Chtimes(expired, old, old)
+
+	mlog, err := log.NewLogger("test",
+		log.WithConsoleModeOff(),
+		log.WithFileMode("", dir, log.DAILYMODE),
+		log.WithFilePattern("{name}.{pid}", 0),
+		log.WithFileMaxSize(50),
+		log.WithFileRetention(24*time.Hour, 1),
+		log.WithFileCompress(),
+		log.WithFileSymlinkOff(),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 5; i++ {
+		mlog.Info("Test Info %d\n", i)
+	}
+	mlog.Close()
+
+	if dat, err := os.ReadFile(other); err != nil || string(dat) != "other process\n" {
+		t.Errorf("expected the file of the other process to be kept, got %q, %v", dat, err)
+	}
+	if _, err := os.Stat(expired); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("expected the expired file of the previous process to be removed, got %v", err)
+	}
+	// the rotated files of the current process are removed, and only its active file is kept
+	if files, _ := filepath.Glob(filepath.Join(dir, fmt.Sprintf("test.%d.*", os.Getpid()))); len(files) != 1 {
+		t.Errorf("expected only the active file of the current process, got %v", files)
+	}
+}
+
+func TestLogFileInterval(t *testing.T) {
+	_, err := log.NewLogger("test",
+		log.WithConsoleModeOff(),
+		log.WithFileMode("", t.TempDir(), log.DAILYMODE),
+		log.WithFilePattern("", 36*time.Hour),
+	)
+	if !errors.Is(err, log.ErrFileInterval) {
+		t.Errorf("expected ErrFileInterval for 36h, got %v", err)
+	}
+}
+
+func TestLogFileSymlink(t *testing.T) {
+	dir := t.TempDir()
+	link := filepath.Join(dir, "test.log")
+	if err := os.WriteFile(link, []byte("stale\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var handled error
+	mlog, err := log.NewLogger("test",
+		log.WithConsoleModeOff(),
+		log.WithFileMode("", dir, log.DAILYMODE),
+		log.WithFileModeFormatter(messageFormatter),
+		log.WithFileMaxSize(10),
+		log.WithErrorHandler(func(output log.OutputMode, err error) {
+			handled = err
+		}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mlog.Info("today 1\n")
+	mlog.Info("today 2\n")
+	mlog.Close()
+
+	today := "test." + time.Now().Format(time.DateOnly)
+	if target, err := os.Readlink(link); err != nil || target != today+".1.log" {
+		t.Errorf("expected the symlink to point at the last segment, got %q: %v", target, err)
+	}
+	if dat, _ := os.ReadFile(link); string(dat) != "today 2\n" {
+		t.Errorf("unexpected content through the symlink: %q", dat)
+	}
+	stale, _ := filepath.Glob(filepath.Join(dir, "test.log.*.old"))
+	if len(stale) != 1 {
+		t.Fatalf("expected the stale file to be moved aside, got %v", stale)
+	}
+	if dat, _ := os.ReadFile(stale[0]); string(dat) != "stale\n" {
+		t.Errorf("unexpected content of the stale file: %q", dat)
+	}
+	if handled != nil {
+		t.Errorf("expected no error for the stale file moved aside, got %v", handled)
+	}
+}
+
+func TestLogFileBuffer(t *testing.T) {
+	dir := t.TempDir()
+	mlog, err := log.NewLogger("test",
+		log.WithConsoleModeOff(),
+		log.WithSyncMode(),
+		log.WithFileMode("", dir, log.DAILYMODE),
+		log.WithFileModeFormatter(messageFormatter),
+		log.WithFileBuffer(4096, time.Hour),
+		log.WithFileFsync(log.FsyncError),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "test."+time.Now().Format(time.DateOnly)+".log")
+	read := func() string {
+		dat, _ := os.ReadFile(path)
+		return string(dat)
+	}
+
+	mlog.Info("Test Info\n")
+	if dat := read(); dat != "" {
+		t.Errorf("expected the entry to be buffered, got %q", dat)
+	}
+	mlog.Error("Test Error\n")
+	if dat := read(); dat != "Test Info\nTest Error\n" {
+		t.Errorf("expected ERROR to flush the buffer, got %q", dat)
+	}
+	mlog.Info("Test Flush\n")
+	if err := mlog.Flush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if dat := read(); !strings.HasSuffix(dat, "Test Flush\n") {
+		t.Errorf("expected Flush to flush the buffer, got %q", dat)
+	}
+	mlog.Info("Test Close\n")
+	mlog.Close()
+	if dat := read(); !strings.HasSuffix(dat, "Test Close\n") {
+		t.Errorf("expected Close to flush the buffer, got %q", dat)
+	}
+}
+
+func TestLogFileFlushInterval(t *testing.T) {
+	dir := t.TempDir()
+	mlog, err := log.NewLogger("test",
+		log.WithConsoleModeOff(),
+		log.WithFileMode("", dir, log.DAILYMODE),
+		log.WithFileBuffer(4096, 10*time.Millisecond),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mlog.Close()
+
+	mlog.Info("Test Info\n")
+	path := filepath.Join(dir, "test."+time.Now().Format(time.DateOnly)+".log")
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if dat, _ := os.ReadFile(path); strings.Contains(string(dat), "Test Info") {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("expected the buffer to be flushed by the interval")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestLogFileReopen(t *testing.T) {
+	dir := t.TempDir()
+	mlog, err := log.NewLogger("test",
+		log.WithConsoleModeOff(),
+		log.WithFileMode("", dir, log.DAILYMODE),
+		log.WithFileModeFormatter(messageFormatter),
+		log.WithReopenSignal(),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "test."+time.Now().Format(time.DateOnly)+".log")
+	rotate := func(suffix string) {
+		if err := mlog.Flush(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Rename(path, path+suffix); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	mlog.Info("Test Reopen\n")
+	rotate(".1")
+	if err := mlog.Reopen(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	mlog.Info("Test Signal\n")
+	rotate(".2")
+
+	if runtime.GOOS != "windows" {
+		process, _ := os.FindProcess(os.Getpid())
+		if err := process.Signal(syscall.SIGHUP); err != nil {
+			t.Fatal(err)
+		}
+		// the signal is handled asynchronously, so log until the file is created again
+		deadline := time.Now().Add(5 * time.Second)
+		for {
+			mlog.Info("Test After\n")
+			_ = mlog.Flush(context.Background())
+			if _, err := os.Stat(path); err == nil {
+				break
+			}
+			if time.Now().After(deadline) {
+				t.Fatal("expected the file to be reopened on SIGHUP")
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+	mlog.Close()
+
+	for name, content := range map[string]string{".1": "Test Reopen\n", ".2": "Test Signal\n"} {
+		if dat, _ := os.ReadFile(path + name); !strings.HasPrefix(string(dat), content) {
+			t.Errorf("unexpected content of %s: %q", name, dat)
+		}
+	}
+	if dat, _ := os.ReadFile(path); runtime.GOOS != "windows" && string(dat) != "Test After\n" {
+		t.Errorf("unexpected content of the reopened file: %q", dat)
+	}
+}
+
+func TestLogFileRemoved(t *testing.T) {
+	dir := t.TempDir()
+	mlog, err := log.NewLogger("test",
+		log.WithConsoleModeOff(),
+		log.WithFileMode("", dir, log.DAILYMODE),
+		log.WithFileModeFormatter(messageFormatter),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "test."+time.Now().Format(time.DateOnly)+".log")
+	mlog.Info("Test Removed\n")
+	if err := mlog.Flush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	// the file is checked at most once a second
+	time.Sleep(1100 * time.Millisecond)
+	mlog.Info("Test Created\n")
+	mlog.Close()
+
+	if dat, _ := os.ReadFile(path); string(dat) != "Test Created\n" {
+		t.Errorf("expected the removed file to be created again, got %q", dat)
+	}
+}
+
+func TestLogFileTruncated(t *testing.T) {
+	dir := t.TempDir()
+	mlog, err := log.NewLogger("test",
+		log.WithConsoleModeOff(),
+		log.WithFileMode("", dir, log.DAILYMODE),
+		log.WithFileModeFormatter(messageFormatter),
+		log.WithFileMaxSize(100),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "test."+time.Now().Format(time.DateOnly)+".log")
+	mlog.Info("%039d\n", 0) // 40 bytes
+	mlog.Info("%039d\n", 1)
+	if err := mlog.Flush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	// logrotate copytruncate copies the file and truncates it in place
+	if err := os.Truncate(path, 0); err != nil {
+		t.Fatal(err)
+	}
+	// the file is checked at most once a second
+	time.Sleep(1100 * time.Millisecond)
+	mlog.Info("%039d\n", 2)
+	mlog.Info("%039d\n", 3)
+	mlog.Close()
+
+	if dat, _ := os.ReadFile(path); len(dat) != 80 {
+		t.Errorf("expected 2 entries in the truncated file, got %q", dat)
+	}
+	if _, err := os.Stat(strings.TrimSuffix(path, ".log") + ".1.log"); err == nil {
+		t.Error("expected no new segment after the file was truncated")
+	}
+}
+
+func TestLogFileValidation(t *testing.T) {
+	dir := t.TempDir()
+	notDir := filepath.Join(dir, "file")
+	if err := os.WriteFile(notDir, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	readOnly := filepath.Join(dir, "readonly")
+	if err := os.Mkdir(readOnly, 0555); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		name    string
+		logPath string
+		err     error
+	}{
+		{name: "not found", logPath: filepath.Join(notDir, "log"), err: log.ErrLogPathNotFound},
+		{name: "permission", logPath: filepath.Join(readOnly, "log"), err: log.ErrLogPathPermission},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if tc.err == log.ErrLogPathPermission && (runtime.GOOS == "windows" || os.Geteuid() == 0) {
+				t.Skip("permissions are not enforced")
+			}
+			_, err := log.NewLogger("test",
+				log.WithConsoleModeOff(),
+				log.WithFileMode("", tc.logPath, log.DAILYMODE),
+			)
+			if !errors.Is(err, tc.err) || !errors.Is(err, log.ErrLogPath) {
+				t.Errorf("expected %v, got %v", tc.err, err)
+			}
+		})
+	}
+
+	mlog, err := log.NewLogger("test", log.WithConsoleModeOff())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mlog.Close()
+	if err := mlog.EnableFileMode("", filepath.Join(notDir, "log"), log.DAILYMODE); !errors.Is(err, log.ErrLogPathNotFound) {
+		t.Errorf("expected ErrLogPathNotFound, got %v", err)
+	}
+	if err := mlog.EnableFileMode("", filepath.Join(dir, "log"), log.DAILYMODE); err != nil {
+		t.Errorf("expected the file mode to be enabled after the error, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "log", "test."+time.Now().Format(time.DateOnly)+".log")); err != nil {
+		t.Errorf("expected the first log file to be created: %v", err)
+	}
+}
+
+func TestLogFileValidationGlobal(t *testing.T) {
+	var buf bytes.Buffer
+	global, err := log.NewLogger("test", log.WithGlobal(), log.WithConsoleOutPut(&buf), log.WithStandardFormatter(messageFormatter))
+	if err != nil {
+		t.Fatal(err)
+	}
+	notDir := filepath.Join(t.TempDir(), "file")
+	if err := os.WriteFile(notDir, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := log.NewLogger("test", log.WithGlobal(), log.WithFileMode("", filepath.Join(notDir, "log"), log.DAILYMODE)); err == nil {
+		t.Fatal("expected an error for the log path")
+	}
+	// the logger that failed does not replace the global logger
+	log.Info("Test Global\n")
+	global.Close()
+	if buf.String() != "Test Global\n" {
+		t.Errorf("expected the entry to be written by the previous global logger, got %q", buf.String())
+	}
+}
@@ -1,8 +1,21 @@
 package tests
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
 	"testing"
 	"time"
 
@@ -26,17 +39,24 @@ func TestLogConsoleFormatterJSON(t *testing.T) {
 	mlog, _ := log.NewLogger("test",
 		log.WithLevel(log.DEBUG),
 		log.WithConsoleMode(),
-		log.WithConsoleFormatter(func(t time.Time, level log.LogLevel, format string, args ...any) string {
+		log.WithConsoleFormatter(func(t time.Time, level log.LogLevel, fields []log.Field, format string, args ...any) string {
 			type JSONFORMAT struct {
-				Time    string       `json:"time"`
-				Level   log.LogLevel `json:"level"`
-				Message string       `json:"message"`
+				Time    string         `json:"time"`
+				Level   log.LogLevel   `json:"level"`
+				Message string         `json:"message"`
+				Fields  map[string]any `json:"fields,omitempty"`
 			}
 
 			var jsonFormat JSONFORMAT
 			jsonFormat.Time = t.Local().String()
 			jsonFormat.Level = level
 			jsonFormat.Message = fmt.Sprintf(format, args...)
+			for _, field := range fields {
+				if jsonFormat.Fields == nil {
+					jsonFormat.Fields = make(map[string]any)
+				}
+				jsonFormat.Fields[field.Key] = field.Value
+			}
 			dat, _ := json.Marshal(&jsonFormat)
 			return fmt.Sprintf("%s\n", dat)
 		}),
@@ -45,6 +65,935 @@ func TestLogConsoleFormatterJSON(t *testing.T) {
 	mlog.Info("Test Info\n")
 	mlog.Warn("Test Warn\n")
 	mlog.Error("Test Error\n")
+	mlog.Infow("Test Infow", "user", "winey", "count", 3)
+	mlog.Close()
+
+}
+
+func TestLogWithFields(t *testing.T) {
+	var buf bytes.Buffer
+	mlog, _ := log.NewLogger("test",
+		log.WithLevel(log.DEBUG),
+		log.WithConsoleOutPut(&buf),
+	)
+	child := mlog.With("request_id", "abc-123")
+	child.Info("Test Info\n")
+	child.Infow("Test Infow", "user", "winey dev", "count", 3)
+	mlog.Infow("Test Parent")
+	mlog.Close()
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("expected 3 lines, got %d: %q", len(lines), buf.String())
+	}
+	if !strings.HasSuffix(lines[0], "[INFO] Test Info request_id=abc-123") {
+		t.Errorf("unexpected line: %q", lines[0])
+	}
+	if !strings.HasSuffix(lines[1], `[INFO] Test Infow request_id=abc-123 user="winey dev" count=3`) {
+		t.Errorf("unexpected line: %q", lines[1])
+	}
+	if !strings.HasSuffix(lines[2], "[INFO] Test Parent") {
+		t.Errorf("unexpected line: %q", lines[2])
+	}
+}
+
+type requestIDKey struct{}
+type traceParentKey struct{}
+
+func TestLogContext(t *testing.T) {
+	var buf bytes.Buffer
+	mlog, _ := log.NewLogger("test",
+		log.WithConsoleOutPut(&buf),
+		log.WithContextExtractor(
+			log.ContextValueExtractor(requestIDKey{}, "request_id"),
+			log.TraceParentExtractor(traceParentKey{}),
+		),
+	)
+	ctx := context.WithValue(context.Background(), requestIDKey{}, "abc-123")
+	ctx = context.WithValue(ctx, traceParentKey{}, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	mlog.InfoCtx(ctx, "Test Info\n")
+	mlog.InfoCtx(context.Background(), "Test Empty\n")
+	mlog.Close()
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 lines, got %d: %q", len(lines), buf.String())
+	}
+	if !strings.HasSuffix(lines[0], "[INFO] Test Info request_id=abc-123 trace_id=4bf92f3577b34da6a3ce929d0e0e4736 span_id=00f067aa0ba902b7") {
+		t.Errorf("unexpected line: %q", lines[0])
+	}
+	if !strings.HasSuffix(lines[1], "[INFO] Test Empty") {
+		t.Errorf("unexpected line: %q", lines[1])
+	}
+}
+
+func TestLogContextTraceParent(t *testing.T) {
+	const ids = "4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7"
+	for traceParent, valid := range map[string]bool{
+		"00-" + ids + "-01":     true,
+		"01-" + ids + "-01-ext": true,
+		"00-" + ids + "-01-ext": false,
+		"ff-" + ids + "-01":     false,
+		"zz-" + ids + "-01":     false,
+		"00-" + ids + "-zz":     false,
+	} {
+		var buf bytes.Buffer
+		mlog, _ := log.NewLogger("test",
+			log.WithConsoleOutPut(&buf),
+			log.WithContextExtractor(log.TraceParentExtractor(traceParentKey{})),
+		)
+		mlog.InfoCtx(context.WithValue(context.Background(), traceParentKey{}, traceParent), "Test Info\n")
+		mlog.Close()
+
+		if got := strings.Contains(buf.String(), "trace_id="); got != valid {
+			t.Errorf("%q: expected trace fields %v, got %q", traceParent, valid, buf.String())
+		}
+	}
+}
+
+func TestLogNamed(t *testing.T) {
+	var buf bytes.Buffer
+	mlog, _ := log.NewLogger("app",
+		log.WithConsoleOutPut(&buf),
+	)
+	db := mlog.Named("db")
+	conn := db.Named("conn").With("id", 1)
+	http := mlog.Named("http")
+	mlog.SetLogLevelFor("app.db", log.DEBUG)
+
+	db.Debug("Test DB Debug\n")
+	conn.Debug("Test Conn Debug\n")
+	http.Debug("Test HTTP Debug\n")
+	http.Info("Test HTTP Info\n")
+	mlog.Close()
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("expected 3 lines, got %d: %q", len(lines), buf.String())
+	}
+	if !strings.HasSuffix(lines[0], "[DEBUG] app.db: Test DB Debug") {
+		t.Errorf("unexpected line: %q", lines[0])
+	}
+	if !strings.HasSuffix(lines[1], "[DEBUG] app.db.conn: Test Conn Debug id=1") {
+		t.Errorf("unexpected line: %q", lines[1])
+	}
+	if !strings.HasSuffix(lines[2], "[INFO] app.http: Test HTTP Info") {
+		t.Errorf("unexpected line: %q", lines[2])
+	}
+}
+
+func TestLogCaller(t *testing.T) {
+	var buf bytes.Buffer
+	mlog, _ := log.NewLogger("test",
+		log.WithConsoleOutPut(&buf),
+		log.WithCaller(0),
+		log.WithGlobal(),
+	)
+	_, file, line, _ := runtime.Caller(0)
+	mlog.Info("Test Info\n")
+	log.Infow("Test Global")
+	mlog.Named("sub").InfoCtx(context.Background(), "Test Ctx\n")
+	mlog.Close()
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("expected 3 lines, got %d: %q", len(lines), buf.String())
+	}
+	for i, l := range lines {
+		want := fmt.Sprintf("caller=%s/%s:%d", filepath.Base(filepath.Dir(file)), filepath.Base(file), line+1+i)
+		if !strings.HasSuffix(l, want) {
+			t.Errorf("expected suffix %q, got %q", want, l)
+		}
+	}
+}
+
+func TestLogStacktrace(t *testing.T) {
+	var buf bytes.Buffer
+	mlog, _ := log.NewLogger("test",
+		log.WithConsoleOutPut(&buf),
+		log.WithStacktrace(log.ERROR),
+	)
+	err := fmt.Errorf("connect: %w", errors.New("connection refused"))
+	mlog.Warn("Test Warn\n")
+	mlog.Error("Test Error %v\n", err)
+	mlog.Close()
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) < 5 {
+		t.Fatalf("expected stack trace lines, got %q", buf.String())
+	}
+	if !strings.HasSuffix(lines[0], "[WARN] Test Warn") {
+		t.Errorf("unexpected line: %q", lines[0])
+	}
+	if !strings.HasSuffix(lines[1], "[ERROR] Test Error connect: connection refused") {
+		t.Errorf("unexpected line: %q", lines[1])
+	}
+	if lines[2] != "\tcaused by: connection refused" {
+		t.Errorf("unexpected error chain: %q", lines[2])
+	}
+	if !strings.HasSuffix(lines[3], ".TestLogStacktrace") || !strings.HasPrefix(lines[3], "\t") {
+		t.Errorf("expected the stack to start at the test function, got %q", lines[3])
+	}
+}
+
+func TestLogFatal(t *testing.T) {
+	var buf bytes.Buffer
+	var exitCode int
+	mlog, _ := log.NewLogger("test",
+		log.WithConsoleOutPut(&buf),
+		log.WithExitFunc(func(code int) { exitCode = code }),
+	)
+	for i := 0; i < 100; i++ {
+		mlog.Info("Test Info %d\n", i)
+	}
+	mlog.Fatal("Test Fatal\n")
+	mlog.Close()
+
+	if exitCode != 1 {
+		t.Errorf("expected exit code 1, got %d", exitCode)
+	}
+	if !strings.HasSuffix(buf.String(), "[FATAL] Test Fatal\n") {
+		t.Errorf("expected the fatal entry to be written last, got %q", buf.String())
+	}
+}
+
+func TestLogFatalWithoutLogger(t *testing.T) {
+	if mode := os.Getenv("GO_LOG_FATAL"); mode != "" {
+		if mode == "w" {
+			log.Fatalw("Test Fatalw", "key", "value")
+		}
+		log.Fatal("Test Fatal %d\n", 1)
+	}
+	for mode, want := range map[string]string{
+		"f": "[FATAL] Test Fatal 1\n",
+		"w": "[FATAL] Test Fatalw key=value\n",
+	} {
+		cmd := exec.Command(os.Args[0], "-test.run=^TestLogFatalWithoutLogger$")
+		cmd.Env = append(os.Environ(), "GO_LOG_FATAL="+mode)
+		var stderr bytes.Buffer
+		cmd.Stderr = &stderr
+		err := cmd.Run()
+		var exitErr *exec.ExitError
+		if !errors.As(err, &exitErr) || exitErr.ExitCode() != 1 {
+			t.Errorf("%s: expected exit code 1, got %v", mode, err)
+		}
+		if !strings.HasSuffix(stderr.String(), want) {
+			t.Errorf("%s: expected the fatal message on stderr, got %q", mode, stderr.String())
+		}
+	}
+}
+
+func TestLogPanic(t *testing.T) {
+	var buf bytes.Buffer
+	mlog, _ := log.NewLogger("test",
+		log.WithConsoleOutPut(&buf),
+	)
+	defer mlog.Close()
+	defer func() {
+		if r := recover(); r != "Test Panic" {
+			t.Errorf("expected panic value %q, got %v", "Test Panic", r)
+		}
+		if !strings.HasSuffix(buf.String(), "[PANIC] Test Panic\n") {
+			t.Errorf("expected the panic entry to be written, got %q", buf.String())
+		}
+	}()
+	mlog.Panic("Test Panic\n")
+}
+
+func TestLogLevelValues(t *testing.T) {
+	stable := map[log.LogLevel]int{log.NONE: 0, log.DEBUG: 1, log.INFO: 2, log.WARN: 3, log.ERROR: 4}
+	for level, value := range stable {
+		if int(level) != value {
+			t.Errorf("expected %s to be %d, got %d", log.LoglevelNames[level], value, int(level))
+		}
+	}
+	if log.TRACE >= log.NONE {
+		t.Errorf("expected TRACE below NONE, got %d", int(log.TRACE))
+	}
+
+	var buf bytes.Buffer
+	mlog, err := log.NewLogger("test",
+		log.WithConsoleOutPut(&buf),
+		log.WithConsoleLevel(log.TRACE),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mlog.Trace("Test Trace\n")
+	mlog.Close()
+	if !strings.HasSuffix(buf.String(), "[TRACE] Test Trace\n") {
+		t.Errorf("expected the trace entry to be written, got %q", buf.String())
+	}
+}
+
+func TestLogVerbosity(t *testing.T) {
+	var buf bytes.Buffer
+	mlog, err := log.NewLogger("app",
+		log.WithLevel(log.TRACE),
+		log.WithConsoleOutPut(&buf),
+		log.WithVerbosity(1),
+		log.WithVModule("app.proto=4"),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mlog.Trace("Test Trace\n")
+	mlog.V(1).Info("Test V1\n")
+	mlog.V(2).Info("Test V2 disabled\n")
+	mlog.Named("proto").V(4).Infow("Test Proto V4")
+	if err := mlog.SetVModule("log_test=3"); err != nil {
+		t.Fatal(err)
+	}
+	mlog.V(3).Info("Test File V3\n")
+	mlog.V(4).Info("Test File V4 disabled\n")
+	if err := mlog.SetVModule("bad"); !errors.Is(err, log.ErrInvalidVModule) {
+		t.Errorf("expected ErrInvalidVModule, got %v", err)
+	}
+	mlog.Close()
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	want := []string{
+		"[TRACE] Test Trace",
+		"[INFO] Test V1",
+		"[INFO] app.proto: Test Proto V4",
+		"[INFO] Test File V3",
+	}
+	if len(lines) != len(want) {
+		t.Fatalf("expected %d lines, got %d: %q", len(want), len(lines), buf.String())
+	}
+	for i := range want {
+		if !strings.HasSuffix(lines[i], want[i]) {
+			t.Errorf("expected suffix %q, got %q", want[i], lines[i])
+		}
+	}
+}
+
+func TestLogOutputLevel(t *testing.T) {
+	var buf bytes.Buffer
+	dir := t.TempDir()
+	mlog, _ := log.NewLogger("test",
+		log.WithLevel(log.INFO),
+		log.WithConsoleOutPut(&buf),
+		log.WithConsoleLevel(log.WARN),
+		log.WithFileMode("", dir, log.DAILYMODE),
+		log.WithFileLevel(log.DEBUG),
+	)
+	mlog.Trace("Test Trace\n")
+	mlog.Debug("Test Debug\n")
+	mlog.Info("Test Info\n")
+	mlog.Warn("Test Warn\n")
+	mlog.Close()
+
+	if strings.Count(buf.String(), "\n") != 1 || !strings.HasSuffix(buf.String(), "[WARN] Test Warn\n") {
+		t.Errorf("unexpected console output: %q", buf.String())
+	}
+	files, _ := filepath.Glob(filepath.Join(dir, "test.*.log"))
+	if len(files) != 1 {
+		t.Fatalf("expected 1 log file, got %v", files)
+	}
+	dat, _ := os.ReadFile(files[0])
+	if strings.Count(string(dat), "\n") != 3 || strings.Contains(string(dat), "Test Trace") {
+		t.Errorf("unexpected file output: %q", dat)
+	}
+}
+
+type captureWriter struct {
+	messages []string
+	fields   [][]log.Field
+	closed   bool
+}
+
+func (c *captureWriter) Write(t time.Time, level log.LogLevel, fields []log.Field, format string, args ...any) (int, error) {
+	c.messages = append(c.messages, fmt.Sprintf(format, args...))
+	c.fields = append(c.fields, fields)
+	return 0, nil
+}
+
+func (c *captureWriter) Close() error {
+	c.closed = true
+	return nil
+}
+
+func TestLogCustomWriter(t *testing.T) {
+	raw := &captureWriter{}
+	formatted := &captureWriter{}
+	mlog, err := log.NewLogger("test",
+		log.WithConsoleModeOff(),
+		log.WithWriter("raw", raw, nil),
+		log.WithWriter("formatted", formatted, func(t time.Time, level log.LogLevel, fields []log.Field, format string, args ...any) string {
+			return log.LoglevelNames[level] + " " + fmt.Sprintf(format, args...)
+		}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mlog.Infow("Test Infow", "key", "value")
+	mlog.Close()
+
+	if len(raw.messages) != 1 || raw.messages[0] != "Test Infow\n" || raw.fields[0][0].Key != "key" {
+		t.Errorf("unexpected raw entries: %q %v", raw.messages, raw.fields)
+	}
+	if len(formatted.messages) != 1 || formatted.messages[0] != "INFO Test Infow\n" {
+		t.Errorf("unexpected formatted entries: %q", formatted.messages)
+	}
+	if !raw.closed || !formatted.closed {
+		t.Error("expected the writers to be closed")
+	}
+
+	if _, err := log.NewLogger("test", log.WithWriter("console", raw, nil)); !errors.Is(err, log.ErrWriterName) {
+		t.Errorf("expected ErrWriterName, got %v", err)
+	}
+	if _, err := log.NewLogger("test", log.WithWriter("nil", nil, nil)); !errors.Is(err, log.ErrNilWriter) {
+		t.Errorf("expected ErrNilWriter, got %v", err)
+	}
+}
+
+func TestLogRuntimeOutput(t *testing.T) {
+	var buf bytes.Buffer
+	dir := t.TempDir()
+	capture := &captureWriter{}
+	mlog, _ := log.NewLogger("test",
+		log.WithConsoleOutPut(&buf),
+	)
+	mlog.Info("Test Console Only\n")
+	if err := mlog.EnableFileMode("", dir, log.DAILYMODE); err != nil {
+		t.Fatal(err)
+	}
+	if err := mlog.EnableFileMode("", dir, log.DAILYMODE); !errors.Is(err, log.ErrOutputExists) {
+		t.Errorf("expected ErrOutputExists, got %v", err)
+	}
+	if err := mlog.AddOutput("capture", capture, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := mlog.AddOutput("nil", nil, nil); !errors.Is(err, log.ErrNilWriter) {
+		t.Errorf("expected ErrNilWriter, got %v", err)
+	}
+	mlog.Info("Test All\n")
+	if err := mlog.DisableFileMode(); err != nil {
+		t.Fatal(err)
+	}
+	if err := mlog.RemoveOutput("capture"); err != nil {
+		t.Fatal(err)
+	}
+	if !capture.closed {
+		t.Error("expected the removed writer to be closed")
+	}
+	if err := mlog.RemoveOutput("capture"); !errors.Is(err, log.ErrOutputNotFound) {
+		t.Errorf("expected ErrOutputNotFound, got %v", err)
+	}
+	mlog.Info("Test Console Again\n")
+	mlog.Close()
+
+	if strings.Count(buf.String(), "\n") != 3 {
+		t.Errorf("unexpected console output: %q", buf.String())
+	}
+	if len(capture.messages) != 1 || capture.messages[0] != "Test All\n" {
+		t.Errorf("unexpected captured entries: %q", capture.messages)
+	}
+	files, _ := filepath.Glob(filepath.Join(dir, "test.*.log"))
+	if len(files) != 1 {
+		t.Fatalf("expected 1 log file, got %v", files)
+	}
+	dat, _ := os.ReadFile(files[0])
+	if !strings.HasSuffix(string(dat), "[INFO] Test All\n") || strings.Count(string(dat), "\n") != 1 {
+		t.Errorf("unexpected file output: %q", dat)
+	}
+}
+
+type blockingWriter struct {
+	captureWriter
+	release chan struct{}
+}
+
+func (b *blockingWriter) Write(t time.Time, level log.LogLevel, fields []log.Field, format string, args ...any) (int, error) {
+	<-b.release
+	return b.captureWriter.Write(t, level, fields, format, args...)
+}
+
+func TestLogBackpressure(t *testing.T) {
+	for _, policy := range []log.BackpressurePolicy{log.BackpressureDropNewest, log.BackpressureDropOldest, log.BackpressureBlockTimeout} {
+		writer := &blockingWriter{release: make(chan struct{})}
+		mlog, _ := log.NewLogger("test",
+			log.WithConsoleModeOff(),
+			log.WithEntrySize(4),
+			log.WithBackpressure(policy, time.Millisecond),
+			log.WithWriter("blocking", writer, nil),
+		)
+		for i := 0; i < 20; i++ {
+			mlog.Info("Test Info %d\n", i)
+		}
+		close(writer.release)
+		mlog.Close()
+
+		dropped := mlog.Dropped()
+		if dropped == 0 {
+			t.Errorf("policy %d: expected dropped entries", policy)
+		}
+		if got := uint64(len(writer.messages)) - 1 + dropped; got != 20 {
+			t.Errorf("policy %d: expected written and dropped entries to add up to 20, got %d", policy, got)
+		}
+		last := writer.messages[len(writer.messages)-1]
+		if last != "log entries were dropped because the queue was full\n" {
+			t.Errorf("policy %d: unexpected report: %q", policy, last)
+		}
+		fields := writer.fields[len(writer.fields)-1]
+		if len(fields) != 1 || fields[0].Key != "dropped" || fields[0].Value != dropped {
+			t.Errorf("policy %d: expected the dropped field to be %d, got %v", policy, dropped, fields)
+		}
+		if policy == log.BackpressureDropOldest && writer.messages[len(writer.messages)-2] != "Test Info 19\n" {
+			t.Errorf("expected the newest entry to be kept, got %q", writer.messages)
+		}
+	}
+}
+
+type countingWriter struct {
+	count atomic.Int64
+}
+
+func (c *countingWriter) Write(t time.Time, level log.LogLevel, fields []log.Field, format string, args ...any) (int, error) {
+	c.count.Add(1)
+	return 0, nil
+}
+
+func TestLogSlowOutput(t *testing.T) {
+	slow := &blockingWriter{release: make(chan struct{})}
+	fast := &countingWriter{}
+	mlog, _ := log.NewLogger("test",
+		log.WithConsoleModeOff(),
+		log.WithEntrySize(64),
+		log.WithWriter("slow", slow, nil),
+		log.WithWriter("fast", fast, nil),
+	)
+	for i := 0; i < 20; i++ {
+		mlog.Info("Test Info %d\n", i)
+	}
+	deadline := time.Now().Add(time.Second)
+	for fast.count.Load() < 20 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if got := fast.count.Load(); got != 20 {
+		t.Errorf("expected the fast output to receive 20 entries while the slow output is blocked, got %d", got)
+	}
+	if depth := mlog.QueueDepth(); depth["slow"] != 19 || depth["fast"] != 0 {
+		t.Errorf("unexpected queue depth: %v", depth)
+	}
+	close(slow.release)
+	mlog.Close()
+
+	if len(slow.messages) != 20 || slow.messages[19] != "Test Info 19\n" {
+		t.Errorf("expected the slow output to receive all entries in order, got %q", slow.messages)
+	}
+}
+
+func TestLogSlowOutputFull(t *testing.T) {
+	slow := &blockingWriter{release: make(chan struct{})}
+	fast := &countingWriter{}
+	mlog, _ := log.NewLogger("test",
+		log.WithConsoleModeOff(),
+		log.WithEntrySize(4),
+		log.WithBackpressure(log.BackpressureDropNewest, 0),
+		log.WithWriter("slow", slow, nil),
+		log.WithWriter("fast", fast, nil),
+	)
+	for i := 0; i < 20; i++ {
+		mlog.Info("Test Info %d\n", i)
+		// wait for the fast output, so the entries are dropped from the queue of the slow output only
+		deadline := time.Now().Add(time.Second)
+		for fast.count.Load() < int64(i+1) && time.Now().Before(deadline) {
+			time.Sleep(time.Millisecond)
+		}
+	}
+	if got := fast.count.Load(); got != 20 {
+		t.Errorf("expected the fast output to receive 20 entries while the queue of the slow output is full, got %d", got)
+	}
+	if depth := mlog.QueueDepth(); depth["slow"] != 4 || depth["fast"] != 0 {
+		t.Errorf("unexpected queue depth: %v", depth)
+	}
+	// one entry is being written by the slow output and 4 are queued
+	if dropped := mlog.Dropped(); dropped != 15 {
+		t.Errorf("expected 15 entries dropped for the slow output, got %d", dropped)
+	}
+	close(slow.release)
+	mlog.Close()
+}
+
+func TestLogSlowOutputBlock(t *testing.T) {
+	slow := &blockingWriter{release: make(chan struct{})}
+	fast := &countingWriter{}
+	mlog, _ := log.NewLogger("test",
+		log.WithConsoleModeOff(),
+		log.WithEntrySize(4),
+		log.WithWriter("slow", slow, nil),
+		log.WithWriter("fast", fast, nil),
+	)
+	var logged atomic.Int64
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 20; i++ {
+			mlog.Info("Test Info %d\n", i)
+			logged.Add(1)
+		}
+	}()
+	// wait until the full queue of the slow output blocks the logging goroutine
+	for last := int64(-1); last != logged.Load(); {
+		last = logged.Load()
+		time.Sleep(20 * time.Millisecond)
+	}
+	if n := logged.Load(); n >= 20 {
+		t.Fatalf("expected the logging goroutine to be blocked, got %d entries logged", n)
+	}
+	// the dispatcher is not blocked: the fast output receives every entry logged, and ops still run
+	deadline := time.Now().Add(time.Second)
+	for fast.count.Load() < logged.Load() && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if got, n := fast.count.Load(), logged.Load(); got != n {
+		t.Errorf("expected the fast output to receive the %d entries logged, got %d", n, got)
+	}
+	if err := mlog.AddOutput("other", &countingWriter{}, nil); err != nil {
+		t.Errorf("expected an output to be added while the slow output is full, got %v", err)
+	}
+	close(slow.release)
+	<-done
+	mlog.Close()
+
+	if got := fast.count.Load(); got != 20 || len(slow.messages) != 20 || slow.messages[19] != "Test Info 19\n" {
+		t.Errorf("expected no entry to be lost, got %d and %q", got, slow.messages)
+	}
+}
+
+func TestLogFlush(t *testing.T) {
+	writer := &blockingWriter{release: make(chan struct{})}
+	mlog, _ := log.NewLogger("test",
+		log.WithConsoleModeOff(),
+		log.WithWriter("slow", writer, nil),
+	)
+	for i := 0; i < 10; i++ {
+		mlog.Info("Test Info %d\n", i)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	err := mlog.Flush(ctx)
+	if !errors.Is(err, context.DeadlineExceeded) || !strings.Contains(err.Error(), `output "slow"`) {
+		t.Errorf("expected a deadline error listing the slow output, got %v", err)
+	}
+
+	close(writer.release)
+	if err := mlog.Flush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if len(writer.messages) != 10 {
+		t.Errorf("expected 10 entries after Flush, got %d", len(writer.messages))
+	}
+	if err := mlog.CloseContext(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLogFlushDropOldest(t *testing.T) {
+	writer := &blockingWriter{release: make(chan struct{})}
+	mlog, _ := log.NewLogger("test",
+		log.WithConsoleModeOff(),
+		log.WithEntrySize(2),
+		log.WithBackpressure(log.BackpressureDropOldest, 0),
+		log.WithWriter("slow", writer, nil),
+	)
+	waitDepth := func(want int) {
+		deadline := time.Now().Add(time.Second)
+		for mlog.QueueDepth()["slow"] != want && time.Now().Before(deadline) {
+			time.Sleep(time.Millisecond)
+		}
+	}
+	mlog.Info("Test Info first\n")
+	waitDepth(0) // the first entry is being written
+
+	flushed := make(chan error, 1)
+	go func() {
+		flushed <- mlog.Flush(context.Background())
+	}()
+	waitDepth(1) // the flush op is at the head of the queue of the output
+	for i := 0; i < 10; i++ {
+		mlog.Info("Test Info %d\n", i)
+	}
+	time.Sleep(10 * time.Millisecond)
+	close(writer.release)
+
+	select {
+	case err := <-flushed:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Flush did not return after the flush op was taken out of the full queue")
+	}
+	closed := make(chan struct{})
+	go func() {
+		mlog.Close()
+		close(closed)
+	}()
+	select {
+	case <-closed:
+	case <-time.After(time.Second):
+		t.Fatal("Close did not return")
+	}
+	if mlog.Dropped() == 0 {
+		t.Error("expected dropped entries")
+	}
+}
+
+func TestLogFlushStuckOutput(t *testing.T) {
+	slow := &blockingWriter{release: make(chan struct{})}
+	fast := &countingWriter{}
+	mlog, _ := log.NewLogger("test",
+		log.WithConsoleModeOff(),
+		log.WithEntrySize(4),
+		log.WithBackpressure(log.BackpressureDropNewest, 0),
+		log.WithWriter("slow", slow, nil),
+		log.WithWriter("fast", fast, nil),
+	)
+	// every entry is logged after the fast output received the previous one, so the shared queue never drops
+	logged := int64(0)
+	logN := func(n int) {
+		for i := 0; i < n; i++ {
+			mlog.Info("Test Info %d\n", i)
+			logged++
+			deadline := time.Now().Add(time.Second)
+			for fast.count.Load() < logged && time.Now().Before(deadline) {
+				time.Sleep(time.Millisecond)
+			}
+		}
+	}
+	logN(10)
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := mlog.Flush(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected a deadline error, got %v", err)
+	}
+
+	// the timed out flush op waits for the slow output without blocking the dispatch to the fast output
+	logN(20)
+	if got := fast.count.Load(); got != 30 {
+		t.Errorf("expected the fast output to receive 30 entries after the flush timed out, got %d", got)
+	}
+	if err := mlog.RemoveOutput("fast"); err != nil {
+		t.Errorf("expected the fast output to be removed, got %v", err)
+	}
+	close(slow.release)
+	mlog.Close()
+}
+
+func TestLogReopenStuckOutput(t *testing.T) {
+	writer := &blockingWriter{release: make(chan struct{})}
+	mlog, _ := log.NewLogger("test",
+		log.WithConsoleModeOff(),
+		log.WithWriter("slow", writer, nil),
+		log.WithReopenSignal(),
+	)
+	mlog.Info("Test Info\n")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := mlog.Reopen(ctx); !errors.Is(err, context.DeadlineExceeded) || !strings.Contains(err.Error(), `output "slow"`) {
+		t.Errorf("expected a deadline error listing the slow output, got %v", err)
+	}
+
+	// a reopen by the signal that waits for the stuck output does not block Close
+	if runtime.GOOS != "windows" {
+		process, _ := os.FindProcess(os.Getpid())
+		if err := process.Signal(syscall.SIGHUP); err != nil {
+			t.Fatal(err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	time.AfterFunc(50*time.Millisecond, func() { close(writer.release) })
+	closed := make(chan struct{})
+	go func() {
+		mlog.Close()
+		close(closed)
+	}()
+	select {
+	case <-closed:
+	case <-time.After(time.Second):
+		t.Fatal("Close did not return")
+	}
+}
+
+func TestLogCloseContext(t *testing.T) {
+	writer := &blockingWriter{release: make(chan struct{})}
+	defer close(writer.release)
+	mlog, _ := log.NewLogger("test",
+		log.WithConsoleModeOff(),
+		log.WithWriter("slow", writer, nil),
+	)
+	mlog.Info("Test Info\n")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := mlog.CloseContext(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected a deadline error, got %v", err)
+	}
+}
+
+func TestLogAfterClose(t *testing.T) {
+	var buf bytes.Buffer
+	dir := t.TempDir()
+	mlog, _ := log.NewLogger("test",
+		log.WithConsoleOutPut(&buf),
+		log.WithFileMode("", dir, log.DAILYMODE),
+		log.WithAfterClose(log.AfterCloseSync),
+	)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				mlog.Info("Test Concurrent\n")
+			}
+		}()
+	}
+	mlog.Close()
+	mlog.Close()
+	wg.Wait()
+	mlog.Info("Test After Close\n")
+	if err := mlog.Flush(context.Background()); !errors.Is(err, log.ErrClosed) {
+		t.Errorf("expected ErrClosed, got %v", err)
+	}
+
+	if strings.Count(buf.String(), "\n") != 801 || !strings.HasSuffix(buf.String(), "[INFO] Test After Close\n") {
+		t.Errorf("expected every entry on the console, got %d lines", strings.Count(buf.String(), "\n"))
+	}
+	files, _ := filepath.Glob(filepath.Join(dir, "test.*.log"))
+	if len(files) != 1 {
+		t.Fatalf("expected 1 log file, got %v", files)
+	}
+	dat, _ := os.ReadFile(files[0])
+	if !strings.HasSuffix(string(dat), "[INFO] Test After Close\n") {
+		t.Errorf("expected the late entry in the file, got %q", dat[max(0, len(dat)-100):])
+	}
+}
+
+func TestLogAfterCloseDiscard(t *testing.T) {
+	var buf bytes.Buffer
+	mlog, _ := log.NewLogger("test",
+		log.WithConsoleOutPut(&buf),
+	)
+	mlog.Close()
+	mlog.Info("Test After Close\n")
+	if buf.Len() != 0 {
+		t.Errorf("expected no output, got %q", buf.String())
+	}
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write(t time.Time, level log.LogLevel, fields []log.Field, format string, args ...any) (int, error) {
+	return 0, errors.New("disk full")
+}
+
+func TestLogErrorHandler(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	var mtx sync.Mutex
+	errs := make(map[log.OutputMode]error)
+	mlog, err := log.NewLogger("test",
+		log.WithConsoleModeOff(),
+		log.WithWriter("failing", failingWriter{}, nil),
+		log.WithRemoteMode(server.URL, http.MethodPost, nil, nil),
+		log.WithErrorHandler(func(output log.OutputMode, err error) {
+			mtx.Lock()
+			defer mtx.Unlock()
+			errs[output] = err
+		}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mlog.Info("Test Info\n")
+	if err := mlog.Flush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
 	mlog.Close()
 
+	if err := errs[log.OutputModeCustom]; err == nil || err.Error() != `output "failing": disk full` {
+		t.Errorf("unexpected custom output error: %v", err)
+	}
+	if err := errs[log.OutputModeRemote]; !errors.Is(err, log.ErrRemoteStatus) {
+		t.Errorf("expected ErrRemoteStatus, got %v", err)
+	}
+}
+
+func TestLogSyncMode(t *testing.T) {
+	w := &captureWriter{}
+	var handled atomic.Int32
+	mlog, err := log.NewLogger("test",
+		log.WithConsoleModeOff(),
+		log.WithSyncMode(),
+		log.WithWriter("capture", w, nil),
+		log.WithWriter("failing", failingWriter{}, nil),
+		log.WithErrorHandler(func(output log.OutputMode, err error) {
+			handled.Add(1)
+		}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mlog.Close()
+
+	err = mlog.InfoE("Test Info\n")
+	if len(w.messages) != 1 || w.messages[0] != "Test Info\n" {
+		t.Errorf("expected the entry to be written before InfoE returns, got %q", w.messages)
+	}
+	if err == nil || err.Error() != `output "failing": disk full` {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if handled.Load() != 1 {
+		t.Errorf("expected the error to be reported to the handler once, got %d", handled.Load())
+	}
+	if err := mlog.DebugE("Test Debug\n"); err != nil {
+		t.Errorf("expected no error for a disabled level, got %v", err)
+	}
+	if err := mlog.RemoveOutput("failing"); err != nil {
+		t.Fatal(err)
+	}
+	if err := mlog.ErrorE("Test Error\n"); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if len(w.messages) != 2 {
+		t.Errorf("expected 2 messages, got %q", w.messages)
+	}
+	if depth := mlog.QueueDepth()[""]; depth != 0 {
+		t.Errorf("expected no queued entries, got %d", depth)
+	}
+}
+
+func TestLogSyncOutput(t *testing.T) {
+	syncWriter := &captureWriter{}
+	asyncWriter := &blockingWriter{release: make(chan struct{})}
+	mlog, err := log.NewLogger("test",
+		log.WithConsoleModeOff(),
+		log.WithWriter("sync", syncWriter, nil),
+		log.WithWriter("async", asyncWriter, nil),
+		log.WithSyncOutput("sync"),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the async output is blocked, so only the sync output has written the entry
+	mlog.Info("Test Info\n")
+	if len(syncWriter.messages) != 1 {
+		t.Errorf("expected the sync output to write before Info returns, got %q", syncWriter.messages)
+	}
+	close(asyncWriter.release)
+	mlog.Close()
+	if len(asyncWriter.messages) != 1 || !syncWriter.closed || !asyncWriter.closed {
+		t.Errorf("expected both outputs to be written and closed, got %q", asyncWriter.messages)
+	}
 }
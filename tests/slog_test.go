@@ -0,0 +1,34 @@
+package tests
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/winey-dev/go-log"
+)
+
+func TestSlogHandler(t *testing.T) {
+	var buf bytes.Buffer
+	mlog, _ := log.NewLogger("test",
+		log.WithLevel(log.INFO),
+		log.WithConsoleOutPut(&buf),
+	)
+	slogger := slog.New(log.NewSlogHandler(mlog))
+	slogger.Debug("Test Debug")
+	slogger.With("service", "api").WithGroup("req").Warn("Test Warn", "id", 7, slog.Group("user", "name", "winey"))
+	slogger.Error("Test Error", slog.Group("empty"))
+	mlog.Close()
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 lines, got %d: %q", len(lines), buf.String())
+	}
+	if !strings.HasSuffix(lines[0], "[WARN] Test Warn service=api req.id=7 req.user.name=winey") {
+		t.Errorf("unexpected line: %q", lines[0])
+	}
+	if !strings.HasSuffix(lines[1], "[ERROR] Test Error") {
+		t.Errorf("unexpected line: %q", lines[1])
+	}
+}
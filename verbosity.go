@@ -0,0 +1,210 @@
+package log
+
+import (
+	"fmt"
+	"path"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// Verbose is returned by V and logs only when the verbosity level is enabled.
+// The entries are logged with the INFO level.
+//
+// example:
+//
+//	mlog.V(3).Info("frame received: %x\n", frame)
+//	if v := mlog.V(5); v.Enabled() {
+//		v.Infow("frame dump", "frame", dump(frame))
+//	}
+type Verbose struct {
+	l       *logger
+	enabled bool
+}
+
+// Enabled reports whether the verbosity level is enabled.
+func (v Verbose) Enabled() bool {
+	return v.enabled
+}
+
+// Info logs a message with the INFO level if the verbosity level is enabled.
+func (v Verbose) Info(format string, args ...any) {
+	if v.enabled {
+		v.l.logf(INFO, format, args...)
+	}
+}
+
+// Infow logs a message with the INFO level and key-value pairs if the verbosity level is enabled.
+func (v Verbose) Infow(msg string, keysAndValues ...any) {
+	if v.enabled {
+		v.l.logw(INFO, msg, keysAndValues...)
+	}
+}
+
+// verbosity holds the verbosity threshold and the vmodule patterns shared by a logger and its children.
+type verbosity struct {
+	level    atomic.Int32
+	mtx      sync.RWMutex
+	patterns []vmodulePattern
+	cache    *sync.Map // vmoduleKey -> int
+}
+
+type vmodulePattern struct {
+	pattern string
+	level   int
+}
+
+type vmoduleKey struct {
+	name string
+	pc   uintptr
+}
+
+// V returns a Verbose that logs only when level is less than or equal to the verbosity of the logger,
+// or of a vmodule pattern that matches the logger name or the file of the caller.
+func (l *logger) V(level int) Verbose {
+	return l.v(level)
+}
+
+// v must be called directly by V or the package-level V so that the caller is the user code.
+func (l *logger) v(level int) Verbose {
+	if level <= int(l.verbosity.level.Load()) {
+		return Verbose{l: l, enabled: true}
+	}
+	var pcs [1]uintptr
+	if runtime.Callers(3, pcs[:]) == 0 {
+		return Verbose{l: l}
+	}
+	moduleLevel, ok := l.verbosity.moduleLevel(l.name, pcs[0])
+	return Verbose{l: l, enabled: ok && level <= moduleLevel}
+}
+
+// SetVerbosity sets the verbosity threshold of the logger and its children.
+func (l *logger) SetVerbosity(level int) {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+	l.config.Verbosity = level
+	l.verbosity.level.Store(int32(level))
+}
+
+// SetVModule sets the per-file and per-logger verbosity in the form "pattern=N,...".
+// A pattern is matched against the dotted logger name (e.g. "app.db" or "app.*"),
+// and against the file name of the caller without ".go" (e.g. "conn" or "proto/*").
+// A pattern that contains "/" is matched against the trailing elements of the file path.
+// The first matching pattern is used. An empty spec removes all patterns.
+func (l *logger) SetVModule(spec string) error {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+	if err := l.verbosity.setVModule(spec); err != nil {
+		return err
+	}
+	l.config.VModule = spec
+	return nil
+}
+
+func (v *verbosity) setVModule(spec string) error {
+	patterns, err := parseVModule(spec)
+	if err != nil {
+		return err
+	}
+	v.mtx.Lock()
+	defer v.mtx.Unlock()
+	v.patterns = patterns
+	v.cache = &sync.Map{}
+	return nil
+}
+
+func parseVModule(spec string) ([]vmodulePattern, error) {
+	var patterns []vmodulePattern
+	for _, item := range strings.Split(spec, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		pattern, value, ok := strings.Cut(item, "=")
+		if !ok || pattern == "" {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidVModule, item)
+		}
+		level, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidVModule, item)
+		}
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidVModule, item)
+		}
+		patterns = append(patterns, vmodulePattern{pattern: pattern, level: level})
+	}
+	return patterns, nil
+}
+
+// moduleLevel returns the level of the first pattern that matches the logger name or the file of pc.
+func (v *verbosity) moduleLevel(name string, pc uintptr) (int, bool) {
+	v.mtx.RLock()
+	defer v.mtx.RUnlock()
+	if len(v.patterns) == 0 {
+		return 0, false
+	}
+	key := vmoduleKey{name: name, pc: pc}
+	if level, ok := v.cache.Load(key); ok {
+		return level.(int), level.(int) >= 0
+	}
+
+	frame, _ := runtime.CallersFrames([]uintptr{pc}).Next()
+	file := strings.TrimSuffix(frame.File, ".go")
+	level := -1
+	for _, p := range v.patterns {
+		if matchName(p.pattern, name) || matchFile(p.pattern, file) {
+			level = p.level
+			break
+		}
+	}
+	v.cache.Store(key, level)
+	return level, level >= 0
+}
+
+func matchName(pattern, name string) bool {
+	ok, _ := path.Match(pattern, name)
+	return ok
+}
+
+func matchFile(pattern, file string) bool {
+	if !strings.Contains(pattern, "/") {
+		ok, _ := path.Match(pattern, path.Base(file))
+		return ok
+	}
+	for i := 0; i < len(file); i++ {
+		if i == 0 || file[i-1] == '/' {
+			if ok, _ := path.Match(pattern, file[i:]); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// V returns a Verbose of the global logger.
+// It returns a disabled Verbose if the global logger is not set.
+func V(level int) Verbose {
+	if globalLogger == nil {
+		return Verbose{}
+	}
+	return globalLogger.v(level)
+}
+
+// SetVerbosity sets the verbosity threshold of the global logger.
+func SetVerbosity(level int) {
+	if globalLogger == nil {
+		return
+	}
+	globalLogger.SetVerbosity(level)
+}
+
+// SetVModule sets the per-file and per-logger verbosity of the global logger.
+// It does nothing if the global logger is not set.
+func SetVModule(spec string) error {
+	if globalLogger == nil {
+		return nil
+	}
+	return globalLogger.SetVModule(spec)
+}